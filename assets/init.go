@@ -9,25 +9,35 @@ import (
 	"fmt"
 	"gogame/config"
 	"gogame/data"
+	"gogame/terrain"
 	"golang.org/x/image/font/gofont/gosmallcaps"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
+	"path/filepath"
 )
 
 var (
+	behaviors    *data.TreeDefs
 	creatures    *data.Creatures
 	objects      *data.Objects
 	resources    *data.Resources
 	spritesheets *data.Spritesheets
 
+	BehaviorTrees   map[string]*data.BehaviorTree
 	CreatureById    map[int]*data.Creature
 	ObjectById      map[int]*data.Object
+	ObjectByTerrain map[string]*data.Object
+	ObjectBySprite  map[[2]int]*data.Object // By spritesheet and sprite IDs
 	ResourceById    map[int]*data.Resource
 	ResourceByType  map[string]*data.Resource
 	SpritesheetById map[int]*data.Spritesheet
 
+	// Terrain metadata of the ground spritesheet
+	Tileset     *terrain.Tileset
+	TilesetPath string
+
 	WorkDir string
 )
 
@@ -44,6 +54,19 @@ func ReadJSON(path string) []byte {
 	return byteValue
 }
 
+func loadTileset(path string) {
+	tsxFile, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer tsxFile.Close()
+	Tileset, err = terrain.LoadTileset(tsxFile)
+	if err != nil {
+		panic(err)
+	}
+	TilesetPath = filepath.Join(WorkDir, path)
+}
+
 func loadSpritesheets() {
 	log.Println(spritesheets)
 
@@ -73,6 +96,7 @@ func InitAssets() {
 	byteValue := ReadJSON("assets/meta/spritesheets.json")
 	json.Unmarshal(byteValue, &spritesheets)
 	loadSpritesheets()
+	loadTileset("assets/tilemap/terrain-v7.tsx")
 
 	// Load objects
 	byteValue = ReadJSON("assets/meta/objects.json")
@@ -82,9 +106,14 @@ func InitAssets() {
 	byteValue = ReadJSON("assets/meta/resources.json")
 	json.Unmarshal(byteValue, &resources)
 
-	// Load creatures
+	// Load creatures and their behaviors
 	byteValue = ReadJSON("assets/meta/creatures.json")
 	json.Unmarshal(byteValue, &creatures)
+	byteValue = ReadJSON("assets/meta/behaviors.json")
+	if err := json.Unmarshal(byteValue, &behaviors); err != nil {
+		panic(err)
+	}
+	loadBehaviorTrees()
 
 	// Prepare hashes for ease of access to loaded assets
 	SpritesheetById = make(map[int]*data.Spritesheet)
@@ -98,8 +127,16 @@ func InitAssets() {
 		ResourceByType[r.Type] = r
 	}
 	ObjectById = make(map[int]*data.Object)
+	ObjectByTerrain = make(map[string]*data.Object)
+	ObjectBySprite = make(map[[2]int]*data.Object)
 	for _, o := range objects.Objects {
 		ObjectById[o.ID] = o
+		if _, ok := ObjectBySprite[[2]int{o.SpritesheetID, o.SpriteID}]; !ok {
+			ObjectBySprite[[2]int{o.SpritesheetID, o.SpriteID}] = o
+		}
+		if o.Terrain != "" {
+			ObjectByTerrain[o.Terrain] = o
+		}
 		o.Spritesheet = spritesheets.Loaded[o.SpritesheetID]
 		spritesheet := SpritesheetById[o.SpritesheetID]
 		o.Animations = spritesheet.Animations
@@ -108,6 +145,18 @@ func InitAssets() {
 	CreatureById = make(map[int]*data.Creature)
 	for _, c := range creatures.Creatures {
 		CreatureById[c.ID] = c
+		c.Tree = GetBehaviorTree(c.Behavior)
+	}
+}
+
+func loadBehaviorTrees() {
+	BehaviorTrees = make(map[string]*data.BehaviorTree)
+	for _, def := range behaviors.Trees {
+		tree, err := data.BuildTree(def)
+		if err != nil {
+			panic(err)
+		}
+		BehaviorTrees[def.Name] = tree
 	}
 }
 
@@ -135,6 +184,20 @@ func GetObjectById(objectID int) *data.Object {
 	panic(fmt.Sprintf("Object '%d' could not be found", objectID))
 }
 
+func GetObjectByTerrain(terrain string) *data.Object {
+	object, ok := ObjectByTerrain[terrain]
+	if ok {
+		return object
+	}
+	panic(fmt.Sprintf("No object for the terrain '%s'", terrain))
+}
+
+// GetObjectBySprite returns the object drawn with the given sprite, if there's any
+func GetObjectBySprite(spritesheetID int, spriteID int) (*data.Object, bool) {
+	object, ok := ObjectBySprite[[2]int{spritesheetID, spriteID}]
+	return object, ok
+}
+
 func GetObjectsByType(resourceType string) []*data.Object {
 	var result []*data.Object
 	for _, v := range objects.Objects {
@@ -145,9 +208,9 @@ func GetObjectsByType(resourceType string) []*data.Object {
 	return result
 }
 
-func GetRandomObjectOfType(resourceType string) *data.Object {
+func GetRandomObjectOfType(r *rand.Rand, resourceType string) *data.Object {
 	objects := GetObjectsByType(resourceType)
-	return objects[rand.Intn(len(objects))]
+	return objects[r.Intn(len(objects))]
 }
 
 func GetSpritesheetById(spritesheetID int) *common.Spritesheet {
@@ -158,6 +221,35 @@ func GetSpritesheetById(spritesheetID int) *common.Spritesheet {
 	panic(fmt.Sprintf("Spritesheet '%d' does not appear to be loaded", spritesheetID))
 }
 
+// GetSpritesheetByFilename finds the spritesheet loaded from a file with the
+// given name, regardless of the directory
+func GetSpritesheetByFilename(filename string) (*data.Spritesheet, bool) {
+	for _, s := range spritesheets.Spritesheets {
+		if filepath.Base(s.FilePath) == filepath.Base(filename) {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// GetCreatureByObjectID returns the creature drawn as the given object, if any
+func GetCreatureByObjectID(objectID int) (*data.Creature, bool) {
+	for _, c := range creatures.Creatures {
+		if c.ObjectID == objectID {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func GetBehaviorTree(name string) *data.BehaviorTree {
+	tree, ok := BehaviorTrees[name]
+	if ok {
+		return tree
+	}
+	panic(fmt.Sprintf("Unknown behavior tree '%s'", name))
+}
+
 func GetCreatureById(creatureID int) *data.Creature {
 	creature, ok := CreatureById[creatureID]
 	if ok {
@@ -8,6 +8,18 @@ const modulo uint8 = 60
 const dayModulo uint8 = 24
 const monthModulo uint8 = 30
 
+// SecondsPerDay is the length of an in-game day in in-game seconds
+const SecondsPerDay uint64 = uint64(modulo) * uint64(modulo) * uint64(dayModulo)
+
+// SecondsPerHour is the length of an in-game hour in in-game seconds
+const SecondsPerHour uint64 = uint64(modulo) * uint64(modulo)
+
+// Hours of the sunrise and the sunset, the same all year round
+const (
+	SunriseHour uint8 = 6
+	SunsetHour  uint8 = 20
+)
+
 type Month uint8
 
 const (
@@ -46,6 +58,29 @@ func (m Month) String() string {
 	}[m]
 }
 
+// Season the month is in, three months each
+func (m Month) Season() Season {
+	return Season(m / 3)
+}
+
+// Next month, the year over after Whitereign
+func (m Month) Next() Month {
+	return (m + 1) % (Whitereign + 1)
+}
+
+type Season uint8
+
+const (
+	Spring Season = iota
+	Summer
+	Autumn
+	Winter
+)
+
+func (s Season) String() string {
+	return [...]string{"spring", "summer", "autumn", "winter"}[s]
+}
+
 type Time struct {
 	SecondsSinceBeginningOfTime uint64
 
@@ -77,15 +112,39 @@ func (self *Time) AddSecond() {
 		self.Day = 0
 		self.Month++
 	}
-	if self.Month == Whitereign {
+	if self.Month > Whitereign {
 		self.Month = 0
 		self.Year++
 	}
 }
 
+func (self *Time) IsNight() bool {
+	return self.Hour < SunriseHour || self.Hour >= SunsetHour
+}
+
+// Season of the year
+func (self *Time) Season() Season {
+	return self.Month.Season()
+}
+
+// MonthProgress is the share of the month gone by, from 0 to 1
+func (self *Time) MonthProgress() float32 {
+	return (float32(self.Day) + float32(self.Hour)/float32(dayModulo)) / float32(monthModulo)
+}
+
+// IsSunrise tells whether the sun has risen this very second
+func (self *Time) IsSunrise() bool {
+	return self.Hour == SunriseHour && self.Minute == 0 && self.Second == 0
+}
+
+// IsSunset tells whether the sun has set this very second
+func (self *Time) IsSunset() bool {
+	return self.Hour == SunsetHour && self.Minute == 0 && self.Second == 0
+}
+
 func (self *Time) GetTextStatus() string {
 	return fmt.Sprintf(
-		"Year %d, day %d of %s\n%02d:%02d", self.Year, self.Day, self.Month,
-		self.Hour, self.Minute,
+		"Year %d, day %d of %s, %s\n%02d:%02d", self.Year, self.Day, self.Month,
+		self.Season(), self.Hour, self.Minute,
 	)
 }
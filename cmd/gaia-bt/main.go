@@ -0,0 +1,132 @@
+// gaia-bt ticks the behavior tree of a single creature against a stub world
+// and prints what the creature does, to try out behaviors.json without
+// running the whole simulation.
+//
+// The stub world of package stubworld is a square of grass around the creature
+// with food at the given offsets, in tiles. Run it from the root of the
+// repository, on machines without a display build it with `-tags headless`:
+//
+//	go build -tags headless ./cmd/gaia-bt
+//	./gaia-bt -food 40 -plants 2,0,-3,1 -seconds 600
+//
+// The tests of the systems package check what the grazer does in such a world.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/EngoEngine/engo"
+	"gogame/assets"
+	"gogame/calendar"
+	"gogame/data"
+	"gogame/systems/stubworld"
+	"gogame/util"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	creatureID = flag.Int("creature-id", 1, "ID of the creature")
+	behavior   = flag.String("behavior", "", "behavior tree to tick instead of the species' one")
+	seconds    = flag.Uint64("seconds", 600, "number of in-game seconds to tick the tree for")
+	hour       = flag.Int("hour", 12, "hour of the day to start at")
+	food       = flag.Float64("food", -1, "food of the creature at the start, the species' initial one if negative")
+	sleep      = flag.Float64("sleep", -1, "sleep of the creature at the start, the species' initial one if negative")
+	plantsAt   = flag.String("plants", "2,0", "comma separated x,y offsets of the food from the creature, in tiles")
+	amount     = flag.Float64("amount", 50, "amount of food of each plant")
+	radius     = flag.Int("radius", 6, "size of the world around the creature, in tiles")
+	seed       = flag.Int64("seed", 1, "seed of the random decisions")
+	verbose    = flag.Bool("verbose", false, "print the log output")
+)
+
+type harnessScene struct{}
+
+func (*harnessScene) Type() string { return "gaia-bt" }
+
+func (*harnessScene) Preload() {
+	assets.InitAssets()
+}
+
+func (*harnessScene) Setup(engo.Updater) {}
+
+func main() {
+	flag.Parse()
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+	offsets, err := parseOffsets(*plantsAt)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	engo.Run(engo.RunOptions{
+		HeadlessMode: true,
+		NoRun:        true,
+	}, &harnessScene{})
+
+	world := stubworld.New(*creatureID, *radius, *seed)
+	c := world.Creature
+	if *behavior != "" {
+		c.Behavior = *behavior
+		c.Tree = assets.GetBehaviorTree(c.Behavior)
+	}
+	if *food >= 0 {
+		c.Food = float32(*food)
+	}
+	if *sleep >= 0 {
+		c.Sleep = float32(*sleep)
+	}
+	world.AddPlants(offsets, float32(*amount))
+	world.Listen()
+
+	t := &calendar.Time{Hour: uint8(*hour)}
+	r := util.NewRand(*seed, "creatures")
+	last := ""
+	for i := uint64(0); i < *seconds; i++ {
+		world.Tick(t, r)
+		// Only print what the creature does when that changes
+		state, health := describe(c)
+		if state != last {
+			fmt.Printf("%02d:%02d:%02d %s, %s\n", t.Hour, t.Minute, t.Second, state, health)
+			last = state
+		}
+	}
+	fmt.Printf("Plants left: %d\n", len(world.Plants()))
+}
+
+func parseOffsets(s string) ([][2]int, error) {
+	var result [][2]int
+	if s == "" {
+		return result, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts)%2 != 0 {
+		return nil, fmt.Errorf("plants need pairs of x,y offsets, got '%s'", s)
+	}
+	for i := 0; i < len(parts); i += 2 {
+		x, err := strconv.Atoi(strings.TrimSpace(parts[i]))
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.Atoi(strings.TrimSpace(parts[i+1]))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, [2]int{x, y})
+	}
+	return result, nil
+}
+
+func describe(c *data.Creature) (string, string) {
+	intent := "nothing"
+	if c.Intent != nil {
+		intent = c.Intent.String()
+	}
+	x, y := util.ToGridIndex(c.SpaceComponent.Position.X, c.SpaceComponent.Position.Y)
+	state := fmt.Sprintf("%s, intends %s, at (%d, %d)", c.Activity, intent, x, y)
+	return state, c.CurrentHealth()
+}
@@ -0,0 +1,201 @@
+// gaia-sim advances the world without a window or GPU and prints a summary.
+//
+// It has to be started from the root of the repository, so that the assets can
+// be found. On machines without a display build it with `-tags headless`:
+//
+//	go build -tags headless ./cmd/gaia-sim
+//	./gaia-sim -days 3 -creatures 10 -predators 2
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"gogame/assets"
+	"gogame/calendar"
+	"gogame/config"
+	"gogame/data"
+	"gogame/messages"
+	"gogame/systems"
+	"gogame/tiled"
+	"gogame/util"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+var (
+	days       = flag.Uint64("days", 1, "number of in-game days to simulate")
+	seed       = flag.Int64("seed", 0, "world seed, a random one is picked if not set")
+	mapPath    = flag.String("map", "", "Tiled map (.tmx) to start from instead of a generated world")
+	exportPath = flag.String("export", "", "write the world to a Tiled map (.tmx) at the end")
+	width      = flag.Int("width", config.WorldWidth, "width of the generated world, in tiles")
+	height     = flag.Int("height", config.WorldHeight, "height of the generated world, in tiles")
+	dt         = flag.Float64("dt", 1, "fixed time step of a single update, in seconds")
+	creatureN  = flag.Int("creatures", 5, "number of creatures to spawn in the generated world")
+	creatureID = flag.Int("creature-id", 1, "ID of the spawned creatures")
+	predatorN  = flag.Int("predators", 0, "number of predators to spawn in the generated world")
+	predatorID = flag.Int("predator-id", 2, "ID of the spawned predators")
+	verbose    = flag.Bool("verbose", false, "print the systems' log output")
+)
+
+type simScene struct {
+	world *ecs.World
+}
+
+func (*simScene) Type() string { return "gaia-sim" }
+
+func (*simScene) Preload() {
+	assets.InitAssets()
+}
+
+// Setup adds the simulation systems only: nothing that renders, scrolls or
+// reads the mouse is needed here.
+func (self *simScene) Setup(u engo.Updater) {
+	self.world, _ = u.(*ecs.World)
+
+	self.world.AddSystem(&systems.SpacialSystem{})
+	self.world.AddSystem(&systems.WorldTilesSystem{})
+	self.world.AddSystem(&systems.SoilSystem{})
+	self.world.AddSystem(&systems.TimeSystem{})
+	self.world.AddSystem(&systems.CreatureSpawningSystem{})
+	self.world.AddSystem(&systems.PlantSpawningSystem{})
+}
+
+func main() {
+	flag.Parse()
+	if *dt <= 0 {
+		fmt.Fprintln(os.Stderr, "dt must be positive")
+		os.Exit(2)
+	}
+	if *width <= 0 || *height <= 0 {
+		fmt.Fprintln(os.Stderr, "width and height must be positive")
+		os.Exit(2)
+	}
+	if !*verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	scene := &simScene{}
+	engo.Run(engo.RunOptions{
+		HeadlessMode: true,
+		NoRun:        true,
+	}, scene)
+
+	var (
+		worldTiles *systems.WorldTilesSystem
+		timeSystem *systems.TimeSystem
+		creatures  *systems.CreatureSpawningSystem
+		plants     *systems.PlantSpawningSystem
+		soil       *systems.SoilSystem
+	)
+	for _, system := range scene.world.Systems() {
+		switch sys := system.(type) {
+		case *systems.WorldTilesSystem:
+			worldTiles = sys
+		case *systems.TimeSystem:
+			timeSystem = sys
+		case *systems.CreatureSpawningSystem:
+			creatures = sys
+		case *systems.PlantSpawningSystem:
+			plants = sys
+		case *systems.SoilSystem:
+			soil = sys
+		}
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	engo.Mailbox.Dispatch(messages.WorldSeedMessage{
+		Seed: *seed,
+	})
+	if *mapPath != "" {
+		if err := worldTiles.ImportMapFile(*mapPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		worldTiles.SetSize(util.WorldSize{Width: *width, Height: *height})
+		worldTiles.Generate()
+	}
+	spawnCreatures(worldTiles, creatures)
+
+	started := time.Now()
+	until := *days * calendar.SecondsPerDay
+	for timeSystem.Time.SecondsSinceBeginningOfTime < until {
+		scene.world.Update(float32(*dt))
+	}
+
+	printSummary(time.Since(started), timeSystem.Time, creatures.Creatures(), plants, soil)
+
+	if *exportPath != "" {
+		if err := tiled.Save(*exportPath, systems.ExportMap(scene.world)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// spawnCreatures puts creatures on randomly picked ground tiles
+func spawnCreatures(worldTiles *systems.WorldTilesSystem, creatures *systems.CreatureSpawningSystem) {
+	// Only where creatures can walk, e.g. not in deep water
+	var ground []*data.Tile
+	for _, t := range worldTiles.Tiles() {
+		if t.Layer != 0 {
+			continue
+		}
+		x, y := util.ToGridIndex(t.SpaceComponent.Position.X, t.SpaceComponent.Position.Y)
+		if _, ok := worldTiles.MovementCost(x, y); ok {
+			ground = append(ground, t)
+		}
+	}
+	if len(ground) == 0 {
+		return
+	}
+	r := util.NewRand(*seed, "spawn")
+	for i := 0; i < *creatureN+*predatorN; i++ {
+		id := *creatureID
+		if i >= *creatureN {
+			id = *predatorID
+		}
+		position := ground[r.Intn(len(ground))].SpaceComponent.Position
+		creatures.Add(systems.NewCreature(id, &position))
+	}
+}
+
+func printSummary(elapsed time.Duration, t *calendar.Time, creatures []*data.Creature, plants *systems.PlantSpawningSystem, soil *systems.SoilSystem) {
+	fmt.Printf("Simulated %d day(s) of world %d in %s\n", *days, *seed, elapsed.Round(time.Millisecond))
+	fmt.Println(t.GetTextStatus())
+
+	alive := 0
+	for _, c := range creatures {
+		if c.IsAlive {
+			alive++
+		}
+	}
+	fmt.Printf("Creatures: %d (alive: %d)\n", len(creatures), alive)
+	for _, c := range creatures {
+		fmt.Printf("  #%d %s, generation %d, %s, %s\n", c.BasicEntity.ID(), c.Name, c.Generation, c.CurrentHealth(), c.CurrentPosition())
+	}
+
+	byName := make(map[string]int)
+	var names []string
+	for _, p := range plants.Plants() {
+		if _, ok := byName[p.Name]; !ok {
+			names = append(names, p.Name)
+		}
+		byName[p.Name]++
+	}
+	sort.Strings(names)
+	fmt.Printf("Plants: %d\n", len(plants.Plants()))
+	for _, name := range names {
+		fmt.Printf("  %s: %d\n", name, byName[name])
+	}
+
+	fertility, moisture := soil.Average()
+	fmt.Printf("Soil: fertility %.2f, moisture %.2f on average\n", fertility, moisture)
+}
@@ -3,6 +3,9 @@ package config
 var (
 	SpriteWidth  = 32
 	SpriteHeight = 32
+	// Size of generated worlds, in tiles
+	WorldWidth  = 50
+	WorldHeight = 50
 	// UI
 	LineHeight              = 20
 	FontURL                 = "go.ttf"
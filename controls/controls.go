@@ -70,6 +70,12 @@ func (self *ControlsSystem) Update(dt float32) {
 			CreatureID: 1,
 		})
 	}
+	if engo.Input.Button("AddPredator").JustPressed() {
+		engo.Mailbox.Dispatch(messages.ControlMessage{
+			Action:     "add_creature",
+			CreatureID: 2,
+		})
+	}
 	if engo.Input.Button("TogglePause").JustPressed() {
 		if !self.paused {
 			engo.Time.Pause()
@@ -102,6 +108,16 @@ func (self *ControlsSystem) Update(dt float32) {
 			Filepath: assets.WorkDir + "/quick.save",
 		})
 	}
+	if engo.Input.Button("ExportMap").JustPressed() {
+		engo.Mailbox.Dispatch(messages.ExportMessage{
+			Filepath: assets.WorkDir + "/world.tmx",
+		})
+	}
+	if engo.Input.Button("SoilOverlay").JustPressed() {
+		engo.Mailbox.Dispatch(messages.ControlMessage{
+			Action: "ToggleSoilOverlay",
+		})
+	}
 	if engo.Input.Button("NewWorld").JustPressed() {
 		engo.Mailbox.Dispatch(messages.ControlMessage{
 			Action: "ReloadWorld",
@@ -0,0 +1,230 @@
+package data
+
+import (
+	"fmt"
+	"github.com/EngoEngine/engo"
+	"gogame/calendar"
+	"gogame/messages"
+	"log"
+	"math/rand"
+)
+
+// The library of conditions and actions which behavior trees are made of
+func init() {
+	RegisterCondition("intends", func(params *Params) (Condition, error) {
+		name, err := params.String("want")
+		if err != nil {
+			return nil, err
+		}
+		want, ok := WantByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown want '%s'", name)
+		}
+		return func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+			return c.Intent != nil && *c.Intent == want
+		}, nil
+	})
+	RegisterCondition("chance", func(params *Params) (Condition, error) {
+		p, err := params.Float("probability", 0.5)
+		return func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+			return r.Float32() < p
+		}, err
+	})
+	registerSimpleCondition("hungry", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.IsHungry()
+	})
+	registerSimpleCondition("satiated", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.IsSatiated()
+	})
+	registerSimpleCondition("tired", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.IsTired()
+	})
+	registerSimpleCondition("rested", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.IsFullyRested()
+	})
+	registerSimpleCondition("bedtime", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.PrefersToSleep(t)
+	})
+	registerSimpleCondition("idle", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.Activity == Idle
+	})
+	registerSimpleCondition("sees_prey", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.Quarry != nil
+	})
+	registerSimpleCondition("has_target", func(c *Creature, t *calendar.Time, r *rand.Rand) bool {
+		return c.Target != nil || c.MovementTarget != nil
+	})
+
+	RegisterAction("find_food", func(params *Params) (ActionFunc, error) {
+		// The perception of the creature if not set
+		radius, err := params.Float("radius", 0)
+		return func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+			return c.seek(radius, c.FindVisibleFood)
+		}, err
+	})
+	registerSimpleAction("recall_food", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		return c.recallFood()
+	})
+	RegisterAction("find_resting_spot", func(params *Params) (ActionFunc, error) {
+		radius, err := params.Float("radius", 3)
+		return func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+			return c.seek(radius, c.FindRestingSpot)
+		}, err
+	})
+	RegisterAction("wander", func(params *Params) (ActionFunc, error) {
+		radius, err := params.Float("radius", 5)
+		return func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+			return c.wander(radius, r)
+		}, err
+	})
+	RegisterAction("flee", func(params *Params) (ActionFunc, error) {
+		radius, err := params.Float("radius", 4)
+		return func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+			return c.flee(radius)
+		}, err
+	})
+	registerSimpleAction("chase", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		return c.chase()
+	})
+	registerSimpleAction("kill", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		return c.kill()
+	})
+	registerSimpleAction("move_to_target", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		return c.moveToTarget()
+	})
+	registerSimpleAction("eat", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		return c.eat()
+	})
+	registerSimpleAction("sleep", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		if c.Activity != Sleeping {
+			log.Println(c, "falls asleep")
+			c.FallAsleep()
+		}
+		return Running
+	})
+	registerSimpleAction("breed", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		if !c.CanBreed(t) {
+			return Failure
+		}
+		engo.Mailbox.Dispatch(messages.BreedMessage{EntityID: c.BasicEntity.ID(), Time: t})
+		if c.LastBred == t.SecondsSinceBeginningOfTime {
+			return Success
+		}
+		// No mate around
+		return Failure
+	})
+	registerSimpleAction("idle", func(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+		c.BecomeIdle()
+		return Success
+	})
+}
+
+func registerSimpleCondition(name string, condition Condition) {
+	RegisterCondition(name, func(*Params) (Condition, error) { return condition, nil })
+}
+
+func registerSimpleAction(name string, action ActionFunc) {
+	RegisterAction(name, func(*Params) (ActionFunc, error) { return action, nil })
+}
+
+// seek succeeds once the creature is at or on its way to a tile accepted by the
+// filter. Otherwise it looks around within the radius, or its perception if
+// it's 0, the world answers right away. It fails while the creature is heading
+// somewhere else.
+func (self *Creature) seek(radius float32, filter func(engo.AABBer) bool) Status {
+	if self.MovementTarget != nil {
+		if filter(self.MovementTarget) {
+			return Success
+		}
+		return Failure
+	}
+	if self.Target != nil && filter(self.Target) {
+		return Success
+	}
+	if radius <= 0 {
+		radius = self.Perception
+	}
+	self.LookFor(radius, filter)
+	if self.MovementTarget != nil {
+		return Success
+	}
+	return Failure
+}
+
+// moveToTarget waits for the creature to walk to the movement target, and
+// succeeds once it's there
+func (self *Creature) moveToTarget() Status {
+	if self.MovementTarget != nil {
+		return Running
+	}
+	if self.Target != nil {
+		return Success
+	}
+	return Failure
+}
+
+// eat feeds on the target until the creature is full or the food runs out
+func (self *Creature) eat() Status {
+	if self.Target == nil || !self.FindFood(self.Target) {
+		return Failure
+	}
+	if self.IsSatiated() {
+		self.BecomeIdle()
+		return Success
+	}
+	if self.Activity != Eating {
+		log.Println(self, "got to the food!")
+		self.Activity = Eating
+		self.Tile.SelectAnimationByName("feed")
+	}
+	eaten := self.EatingSpeed
+	self.Feed(eaten)
+	self.Target.AccessibleResource.Amount -= eaten
+	log.Println(self, "eating", eaten, self.Food)
+	if self.Target.AccessibleResource.Amount <= 0 {
+		// Ate it all
+		log.Println("Ate all of", self.Target)
+		engo.Mailbox.Dispatch(messages.TileRemoveMessage{
+			Entity: self.Target.BasicEntity,
+		})
+		self.BecomeIdle()
+		return Success
+	}
+	return Running
+}
+
+// wander picks a random spot within the radius to walk to, now and then, or
+// one its flock steers it to. It succeeds while the creature is on its way there.
+func (self *Creature) wander(radius float32, r *rand.Rand) Status {
+	switch self.Activity {
+	case Wandering:
+		if self.MovementTarget != nil {
+			return Success
+		}
+		self.BecomeIdle()
+	case Idle:
+		if !self.DecideToWander(r) {
+			return Failure
+		}
+		self.Activity = Wandering
+		self.LastEventID++
+		request := messages.SpacialRequestMessage{
+			Aabb:     self.SurroundingAreaAABB(radius),
+			Filter:   func(aabb engo.AABBer) bool { return true },
+			EntityID: self.BasicEntity.ID(),
+			EventID:  self.LastEventID,
+		}
+		if self.IsSocial() {
+			// Heads for the ground nearest to where the flock steers it
+			goal := self.flockGoal(radius, r)
+			request.From = &goal
+			request.Filter = self.FindRestingSpot
+		}
+		engo.Mailbox.Dispatch(request)
+		if self.MovementTarget != nil {
+			return Success
+		}
+		self.BecomeIdle()
+	}
+	return Failure
+}
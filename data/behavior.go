@@ -0,0 +1,92 @@
+package data
+
+import (
+	"gogame/calendar"
+	"log"
+)
+
+// Hysteresis is how much more urgent another want has to be to distract a
+// creature from the one it pursues, so it doesn't dither between two equally
+// pressing needs
+const Hysteresis float32 = 0.2
+
+// Behavior is how creatures feel a want. How they satisfy it is up to the
+// behavior tree of their species.
+type Behavior interface {
+	// Urgency scores how pressing the want is, 0 if the creature doesn't feel it
+	// at all. The need is nil unless the creature already has it.
+	Urgency(c *Creature, need *Need, t *calendar.Time) float32
+}
+
+var (
+	behaviors = make(map[Want]Behavior)
+	wantNames = make(map[Want]string)
+	// Wants in the order they were registered, which settles ties
+	wants []Want
+)
+
+// RegisterBehavior makes creatures feel a want
+func RegisterBehavior(want Want, name string, behavior Behavior) {
+	if _, ok := behaviors[want]; !ok {
+		wants = append(wants, want)
+	}
+	behaviors[want] = behavior
+	wantNames[want] = name
+}
+
+// WantByName finds a registered want
+func WantByName(name string) (Want, bool) {
+	for want, n := range wantNames {
+		if n == name {
+			return want, true
+		}
+	}
+	return 0, false
+}
+
+// Decide scores the wants of the creature, keeps its needs up to date and
+// makes the most urgent one its intent
+func (self *Creature) Decide(t *calendar.Time) {
+	var best Want
+	var bestScore, current float32
+	for _, want := range wants {
+		score := behaviors[want].Urgency(self, self.NeedFor(want), t)
+		if score <= 0 {
+			self.RemoveNeedFor(want)
+			continue
+		}
+		if self.AddNeedFor(want) {
+			log.Println(self, "needs", want, self.Needs)
+		}
+		if self.Intent != nil && *self.Intent == want {
+			current = score
+		}
+		if score > bestScore {
+			best, bestScore = want, score
+		}
+	}
+
+	switch {
+	case bestScore == 0:
+		if self.Intent != nil {
+			self.pursue(nil)
+		}
+	case self.Intent == nil:
+		self.pursue(&best)
+	case *self.Intent != best && (current == 0 || bestScore > current+Hysteresis):
+		self.pursue(&best)
+	}
+}
+
+// pursue drops whatever the creature was doing for another want, or none
+func (self *Creature) pursue(want *Want) {
+	log.Println(self, "pursues", want)
+	self.Intent = want
+	self.MovementTarget = nil
+	self.Path = nil
+	if self.Activity == Sleeping {
+		self.WakeUp()
+	} else {
+		self.BecomeIdle()
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"gogame/messages"
 	"gogame/util"
 	"log"
+	"math/rand"
 	"strings"
 	"time"
 )
@@ -23,47 +24,86 @@ const (
 	Eating
 	Wandering
 	Sleeping
+	Dead
+	Fleeing
+	Hunting
 )
 
 const (
 	Food Want = iota
 	Sleep
+	Breed
+	Safety
 )
 
 func (a Activity) String() string {
-	return [...]string{"idle", "eating", "wandering"}[a]
+	return [...]string{"idle", "eating", "wandering", "sleeping", "dead", "fleeing", "hunting"}[a]
 }
 
 func (w Want) String() string {
-	return [...]string{"food", "sleep"}[w]
+	if name, ok := wantNames[w]; ok {
+		return name
+	}
+	return fmt.Sprintf("want %d", w)
 }
 
 type Creature struct {
 	*Tile `deepcopier:"skip"`
 
 	// Species properties, immutable
-	ID            int     `json:"id"`
-	ObjectID      int     `json:"object_id"`
-	EatingSpeed   float32 `json:"eating_speed"`
-	Eats          []int   `json:"eats"` // Resource IDs
-	MaxFood       float32 `json:"max_food"`
-	MaxSleep      float32 `json:"max_sleep"`
-	MinFood       float32 `json:"min_food"`
-	MinSleep      float32 `json:"min_sleep"`
-	MovementSpeed float32 `json:"movement_speed"`
-	Species       string  `json:"species"`
+	ID               int     `json:"id"`
+	ObjectID         int     `json:"object_id"`
+	Behavior         string  `json:"behavior"`      // Name of the behavior tree
+	CorpseID         int     `json:"corpse_id"`     // Object left behind when it dies, none if 0
+	DecayRate        float32 `json:"decay_rate"`    // Resource of the corpse lost per hour
+	Diurnal          bool    `json:"diurnal"`       // Sleeps at night rather than during the day
+	Eats             []int   `json:"eats"`          // Resource IDs
+	FatigueRate      float32 `json:"fatigue_rate"`  // Sleep lost per hour awake
+	FieldOfView      float32 `json:"field_of_view"` // In degrees around its heading, all around if 0
+	HealingRate      float32 `json:"healing_rate"`  // Health regained per hour while not hungry
+	MaxHealth        float32 `json:"max_health"`
+	MemoryDecay      float32 `json:"memory_decay"` // Strength of memories lost per hour
+	Perception       float32 `json:"perception"`   // Radius in tiles it sees within
+	Prey             []int   `json:"prey"`         // Creature IDs it hunts
+	RestRate         float32 `json:"rest_rate"`    // Sleep regained per hour asleep
+	Species          string  `json:"species"`
+	StarvationDamage float32 `json:"starvation_damage"` // Health lost per hour without food
+	Breeding
+	Flocking
+	Metabolism
+
+	// Heritable properties, fixed for the life of the creature
+	Genome
+	Generation int `json:"generation"`
 
 	// Live properties, mutable
-	Activity       Activity `json:"activity"`
-	Food           float32  `json:"food"`
-	IsAlive        bool     `json:"is_alive"`
-	MovementTarget *Tile    `json:"movement_target"`
-	Name           string   `json:"name"`
-	Needs          []*Need  `json:"needs"`
-	Sleep          float32  `json:"sleep"`
-	Target         *Tile    `json:"target"`
+	Activity       Activity      `json:"activity"`
+	Age            time.Duration `json:"age"` // In-game time since birth
+	CauseOfDeath   string        `json:"cause_of_death"`
+	Food           float32       `json:"food"`
+	EnergyBalance  float32       `json:"energy_balance"` // Food eaten less food burnt, per hour
+	Heading        engo.Point    `json:"heading"`        // Direction of the last step, of unit length
+	Health         float32       `json:"health"`
+	Intent         *Want         `json:"intent"` // Want the creature acts upon
+	IsAlive        bool          `json:"is_alive"`
+	LastBred       uint64        `json:"last_bred"` // In seconds since the beginning of time
+	Memories       []*Memory     `json:"memories"`
+	MovementTarget *Tile         `json:"movement_target"`
+	Name           string        `json:"name"`
+	Needs          []*Need       `json:"needs"`
+	Path           []engo.Point  `json:"path"` // Waypoints to the movement target
+	Sleep          float32       `json:"sleep"`
+	Target         *Tile         `json:"target"`
 
 	LastEventID uint64 `json:"last_event_id"`
+
+	Tree   *BehaviorTree `json:"-" deepcopier:"skip"`
+	energy energyFlow
+	// What the creature perceives, updated every second
+	Quarry     *Creature   `json:"-" deepcopier:"skip"` // Nearest prey
+	Threat     *Creature   `json:"-" deepcopier:"skip"` // Nearest predator
+	Flockmates []*Creature `json:"-" deepcopier:"skip"`
+	Leader     *Creature   `json:"-" deepcopier:"skip"` // Eldest of the flock, nil if it's the creature itself
 }
 
 type Creatures struct {
@@ -72,7 +112,7 @@ type Creatures struct {
 
 func (self *Creature) FindFood(x engo.AABBer) bool {
 	if tile, ok := x.(*Tile); ok {
-		fmt.Println("Checking", tile, self.Eats, "resource:", tile.Resource)
+		log.Println("Checking", tile, self.Eats, "resource:", tile.Resource)
 		if tile.Resource != nil && util.ContainsInt(self.Eats, tile.Resource.ID) && tile.AccessibleResource.Amount > 0 {
 			return true
 		}
@@ -101,123 +141,117 @@ func (self *Creature) BecomeIdle() {
 	self.Activity = Idle
 }
 
-func (self *Creature) DecideToWander() bool {
-	return util.Roll(0.3, 0.9) > 0.5
+func (self *Creature) DecideToWander(r *rand.Rand) bool {
+	if self.Strayed() {
+		// Catches up with the flock
+		return true
+	}
+	return util.Roll(r, 0.3, 0.9) > 0.5
 }
 
+// Update is ran every frame, with `dt` being the seconds of game time since
+// the last one
 func (self *Creature) Update(dt float32) {
-	// Handle movement
-	// TODO write the SpeedComponent and remove movement logic from here completely.
-	if self.MovementTarget != nil {
-		if self.TooFar(self.MovementTarget, 0.1) {
-			v := self.Direction(self.MovementTarget)
-			log.Println("Moving", v)
-			self.Tile.SpaceComponent.Position.Add(*v.MultiplyScalar(dt))
-			// TODO speed and smooth movement
-		} else {
-			log.Println(self, "reached the movement target", self.MovementTarget)
-			self.BecomeIdle()
-			self.Target = self.MovementTarget
-			self.MovementTarget = nil
-			self.Tile.AnimationComponent.CurrentAnimation = nil
-		}
+	if !self.IsAlive {
+		return
 	}
+	self.Move(dt)
 }
 
-func (self *Creature) UpdateActivity(currentTime *calendar.Time) {
+func (self *Creature) UpdateActivity(currentTime *calendar.Time, r *rand.Rand) {
+	if !self.IsAlive {
+		self.Decay()
+		return
+	}
 	// Handle durations of needs
 	for _, n := range self.Needs {
 		log.Println(n, n.Duration, time.Duration(int64(time.Second)))
 		n.Duration += time.Duration(int64(time.Second))
 	}
-	if self.Activity != Eating {
-		// Expend them calories TODO moving increases, sleeping decreases
-		self.Food -= self.EatingSpeed
+	self.Metabolise()
+	if self.Activity == Sleeping {
+		self.Sleep += self.RestRate / float32(calendar.SecondsPerHour)
+	} else {
+		self.Sleep -= self.FatigueRate / float32(calendar.SecondsPerHour)
 	}
-	// Handle hunger
-	if self.IsHungry() && !self.HasNeedFor(Food) {
-		self.AddNeedFor(Food)
-		log.Println(self, "needs food!", self.Needs)
+	if self.Sleep > self.MaxSleep {
+		self.Sleep = self.MaxSleep
+	} else if self.Sleep < 0 {
+		self.Sleep = 0
 	}
-	if len(self.Needs) > 0 && self.Needs[0].Want == Food && self.MovementTarget == nil && self.Activity != Eating {
-		if self.Target == nil {
-			engo.Mailbox.Dispatch(messages.SpacialRequestMessage{
-				Aabb:     self.SurroundingAreaAABB(2),
-				Filter:   self.FindFood,
-				EntityID: self.BasicEntity.ID(),
-				EventID:  self.LastEventID + 1,
-			})
-			self.LastEventID++
-		} else {
-			if self.FindFood(self.Target) {
-				log.Println(self, "got to the food!")
-				self.Activity = Eating
-				self.Tile.SelectAnimationByName("feed")
-			} else {
-				self.BecomeIdle()
-			}
-		}
+	if !self.Live() {
+		return
 	}
-	// Handle movement
-	// TODO write the SpeedComponent and remove movement logic from here completely.
-	if self.MovementTarget != nil {
-		if self.TooFar(self.MovementTarget, 0.1) {
-			v := self.Direction(self.MovementTarget)
-			log.Println("Moving", v)
-			self.Tile.SpaceComponent.Position.Add(*v)
-			// TODO speed and smooth movement
-		} else {
-			self.Target = self.MovementTarget
-			self.MovementTarget = nil
-		}
+	self.Fade()
+	self.Decide(currentTime)
+
+	if self.Tree != nil {
+		self.Tree.Tick(self, currentTime, r)
 	}
+}
 
-	// Handle eating
-	if self.Activity == Eating && self.Target != nil {
-		if !self.IsSatiated() {
-			target := *self.Target
-			if target.AccessibleResource.Amount > 0 {
-				eaten := self.EatingSpeed
-				self.Food += eaten
-				self.Target.AccessibleResource.Amount -= eaten
-				log.Println(self, "eating", eaten, self.Food)
-			}
-			if target.AccessibleResource.Amount <= 0 {
-				// Ate it all
-				log.Println("Ate all of", self.Target)
-				engo.Mailbox.Dispatch(messages.TileRemoveMessage{
-					Entity: self.Target.BasicEntity,
-				})
-				self.BecomeIdle()
-			}
-		} else {
-			self.BecomeIdle()
-			self.RemoveNeedFor(Food)
-		}
+// LookFor queries the surroundings within the radius for tiles accepted by the
+// filter, nearest first
+func (self *Creature) LookFor(radius float32, filter func(engo.AABBer) bool) {
+	self.LastEventID++
+	engo.Mailbox.Dispatch(messages.SpacialRequestMessage{
+		Aabb:     self.SurroundingAreaAABB(radius),
+		Filter:   filter,
+		From:     &self.Tile.SpaceComponent.Position,
+		EntityID: self.BasicEntity.ID(),
+		EventID:  self.LastEventID,
+	})
+}
+
+// FindRestingSpot accepts the ground which the creature can comfortably sleep on
+func (self *Creature) FindRestingSpot(x engo.AABBer) bool {
+	tile, ok := x.(*Tile)
+	return ok && tile.Layer == 0 && tile.Object != nil && !tile.Object.Impassable && tile.Object.MovementCost <= 1
+}
+
+// PrefersToSleep tells whether the species sleeps at the time of the day
+func (self *Creature) PrefersToSleep(t *calendar.Time) bool {
+	return t.IsNight() == self.Diurnal
+}
+
+func (self *Creature) FallAsleep() {
+	self.Activity = Sleeping
+	self.MovementTarget = nil
+	self.Path = nil
+	if self.Tile.AnimationComponent != nil {
+		self.Tile.AnimationComponent.CurrentAnimation = nil
 	}
+}
 
-	// Handle idling
-	if self.Activity == Idle && len(self.Needs) == 0 {
-		if self.Activity != Wandering && self.DecideToWander() {
-			self.Activity = Wandering
-			engo.Mailbox.Dispatch(messages.SpacialRequestMessage{
-				Aabb:     self.SurroundingAreaAABB(5),
-				Filter:   func(aabb engo.AABBer) bool { return true },
-				EntityID: self.BasicEntity.ID(),
-				EventID:  self.LastEventID + 1,
-			})
-			self.LastEventID++
+func (self *Creature) WakeUp() {
+	log.Println(self, "wakes up")
+	self.BecomeIdle()
+}
+
+// ReactToDaylight makes the creature sleepy once its time to sleep comes, or
+// lets it wake up when that time is over, unless it's still tired
+func (self *Creature) ReactToDaylight(t *calendar.Time) {
+	if self.PrefersToSleep(t) {
+		if !self.IsFullyRested() {
+			self.AddNeedFor(Sleep)
 		}
+	} else if !self.IsTired() {
+		self.RemoveNeedFor(Sleep)
 	}
 }
 
 func (self *Creature) HasNeedFor(want Want) bool {
+	return self.NeedFor(want) != nil
+}
+
+// NeedFor returns the need of the creature for the want, nil if it has none
+func (self *Creature) NeedFor(want Want) *Need {
 	for _, n := range self.Needs {
 		if n.Want == want {
-			return true
+			return n
 		}
 	}
-	return false
+	return nil
 }
 
 func (self *Creature) AddNeedFor(want Want) bool {
@@ -252,18 +286,24 @@ func (self *Creature) CurrentNeeds() string {
 }
 
 func (self *Creature) CurrentHealth() string {
+	if !self.IsAlive {
+		return fmt.Sprintf("Died of %s at %s", self.CauseOfDeath, util.FormatAge(self.Age))
+	}
 	return fmt.Sprintf(
-		"Food: %d/%d, sleep: %d/%d",
+		"Age: %s, health: %d/%d, food: %d/%d, sleep: %d/%d",
+		util.FormatAge(self.Age), int(self.Health), int(self.MaxHealth),
 		int(self.Food), int(self.MaxFood), int(self.Sleep), int(self.MaxSleep),
 	)
 }
 
 func (self *Creature) GetTextStatus() string {
 	return fmt.Sprintf(
-		"%s, %s \nNeeds %s \n%s \n%s \n%s \n",
-		self.Name, self.Species,
+		"%s, %s, generation %d \nNeeds %s \n%s \n%s \n%s \n%s \n%s \n",
+		self.Name, self.Species, self.Generation,
 		self.CurrentNeeds(),
 		self.CurrentHealth(),
+		self.CurrentEnergy(),
+		self.CurrentMemories(),
 		self.Activity,
 		self.CurrentPosition(),
 	)
@@ -0,0 +1,114 @@
+package data
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/config"
+	"math"
+	"math/rand"
+)
+
+// Flocking is how a social species keeps together, a species is solitary if
+// its flock radius is 0. The weights steer where its creatures wander to.
+type Flocking struct {
+	FlockRadius  float32 `json:"flock_radius"`  // Tiles within which other creatures of the species are flockmates
+	Cohesion     float32 `json:"cohesion"`      // Share of the way to the centre of the flock
+	Separation   float32 `json:"separation"`    // Push away from flockmates closer than the spacing
+	Spacing      float32 `json:"spacing"`       // Tiles kept between flockmates
+	Alignment    float32 `json:"alignment"`     // Share of a wander taken along the heading of the flock
+	FollowLeader float32 `json:"follow_leader"` // Share of the way to the leader
+}
+
+// IsSocial tells whether the creature keeps to a flock
+func (self *Creature) IsSocial() bool {
+	return self.FlockRadius > 0
+}
+
+// Strayed tells whether a social creature is too far from its leader, or from
+// the flock if it leads
+func (self *Creature) Strayed() bool {
+	if !self.IsSocial() || len(self.Flockmates) == 0 {
+		return false
+	}
+	position := self.Tile.SpaceComponent.Position
+	away := position.PointDistance(self.flockCentre())
+	if self.Leader != nil {
+		away = position.PointDistance(self.Leader.Tile.SpaceComponent.Position)
+	}
+	return away > self.FlockRadius*float32(config.SpriteWidth)/2
+}
+
+func (self *Creature) flockCentre() engo.Point {
+	var centre engo.Point
+	for _, m := range self.Flockmates {
+		centre.Add(m.Tile.SpaceComponent.Position)
+	}
+	centre.MultiplyScalar(1 / float32(len(self.Flockmates)))
+	return centre
+}
+
+// flockGoal is where a creature heads when it wanders: a random spot within
+// the radius, steered by its flock
+func (self *Creature) flockGoal(radius float32, r *rand.Rand) engo.Point {
+	position := self.Tile.SpaceComponent.Position
+	reach := radius * float32(config.SpriteWidth)
+	if self.Leader != nil {
+		// Followers stray less than the leader, so the flock moves as one
+		reach /= 2
+	}
+	goal := engo.Point{
+		X: position.X + (r.Float32()*2-1)*reach,
+		Y: position.Y + (r.Float32()*2-1)*reach,
+	}
+	if len(self.Flockmates) == 0 {
+		return goal
+	}
+
+	var steering, heading engo.Point
+	toCentre := self.flockCentre()
+	toCentre.Subtract(position)
+	steering.Add(*toCentre.MultiplyScalar(self.Cohesion))
+
+	spacing := self.Spacing * float32(config.SpriteWidth)
+	for _, m := range self.Flockmates {
+		heading.Add(m.Heading)
+		away := position
+		away.Subtract(m.Tile.SpaceComponent.Position)
+		d := length(away)
+		if d >= spacing {
+			continue
+		}
+		if d == 0 {
+			away, d = engo.Point{X: 1}, 1
+		}
+		steering.Add(*away.MultiplyScalar((spacing - d) / d * self.Separation))
+	}
+	if d := length(heading); d > 0 {
+		steering.Add(*heading.MultiplyScalar(radius * float32(config.SpriteWidth) / d * self.Alignment))
+	}
+	if self.Leader != nil {
+		toLeader := self.Leader.Tile.SpaceComponent.Position
+		toLeader.Subtract(position)
+		steering.Add(*toLeader.MultiplyScalar(self.FollowLeader))
+	}
+	goal.Add(steering)
+	return goal
+}
+
+// FindUnclaimedFood accepts food which no flockmate is eating or heading to
+// already, so a flock spreads out while grazing
+func (self *Creature) FindUnclaimedFood(x engo.AABBer) bool {
+	if !self.FindFood(x) {
+		return false
+	}
+	for _, m := range self.Flockmates {
+		if (m.Target != nil && engo.AABBer(m.Target) == x) ||
+			(m.MovementTarget != nil && engo.AABBer(m.MovementTarget) == x) {
+			return false
+		}
+	}
+	return true
+}
+
+func length(p engo.Point) float32 {
+	return float32(math.Hypot(float64(p.X), float64(p.Y)))
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"gogame/calendar"
+	"gogame/util"
+	"math/rand"
+)
+
+// Breeding is how a species reproduces
+type Breeding struct {
+	BreedingFood     float32 `json:"breeding_food"`     // Food needed to breed, the species doesn't if 0
+	BreedingCost     float32 `json:"breeding_cost"`     // Food passed on to the offspring
+	BreedingInterval float32 `json:"breeding_interval"` // Hours between two broods
+	MateRadius       float32 `json:"mate_radius"`       // Tiles to find a mate within, 0 if it breeds alone
+	MutationRate     float32 `json:"mutation_rate"`     // Of the traits of the offspring, relative to their values
+}
+
+// Genome holds the traits a creature inherits from its parents. A species'
+// record in creatures.json is the genome of the first generation.
+type Genome struct {
+	EatingSpeed   float32 `json:"eating_speed"`
+	Lifespan      float32 `json:"lifespan"` // In days, it never dies of old age if 0
+	MaxFood       float32 `json:"max_food"`
+	MaxSleep      float32 `json:"max_sleep"`
+	MinFood       float32 `json:"min_food"`
+	MinSleep      float32 `json:"min_sleep"`
+	MovementSpeed float32 `json:"movement_speed"` // In pixels per second of game time
+}
+
+func (self *Genome) traits() []*float32 {
+	return []*float32{
+		&self.EatingSpeed,
+		&self.Lifespan,
+		&self.MaxFood,
+		&self.MaxSleep,
+		&self.MinFood,
+		&self.MinSleep,
+		&self.MovementSpeed,
+	}
+}
+
+// Inherit picks each trait from one of the parents at random and mutates it:
+// the standard deviation of the mutation is `rate` times the value
+func Inherit(r *rand.Rand, rate float32, parents ...*Genome) Genome {
+	var child Genome
+	traits := child.traits()
+	for i, t := range traits {
+		parent := parents[r.Intn(len(parents))]
+		value := *parent.traits()[i]
+		*t = float32(util.Roll(r, float64(value*rate), float64(value)))
+		if *t < 0 {
+			*t = 0
+		}
+	}
+	// Thresholds must stay within the capacity
+	if child.MinFood > child.MaxFood {
+		child.MinFood = child.MaxFood
+	}
+	if child.MinSleep > child.MaxSleep {
+		child.MinSleep = child.MaxSleep
+	}
+	return child
+}
+
+// CanBreed tells whether the creature is well fed and has recovered from its
+// last brood, or birth
+func (self *Creature) CanBreed(t *calendar.Time) bool {
+	if self.BreedingFood <= 0 || self.Food < self.BreedingFood {
+		return false
+	}
+	interval := uint64(self.BreedingInterval * float32(calendar.SecondsPerHour))
+	return self.LastBred == 0 || t.SecondsSinceBeginningOfTime >= self.LastBred+interval
+}
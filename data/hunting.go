@@ -0,0 +1,110 @@
+package data
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/config"
+	"gogame/messages"
+	"gogame/util"
+	"log"
+	"math"
+)
+
+// Hunts tells whether the creature preys on the other one
+func (self *Creature) Hunts(other *Creature) bool {
+	return other.IsAlive && util.ContainsInt(self.Prey, other.ID)
+}
+
+// Reaches tells whether the tile is within a pounce, a tile away at most
+func (self *Creature) Reaches(tile *Tile) bool {
+	return !self.TooFar(tile, float32(config.SpriteWidth))
+}
+
+// chase runs after the quarry and succeeds once it's within reach. The way is
+// found again whenever the quarry has moved away from where the path ends.
+func (self *Creature) chase() Status {
+	quarry := self.Quarry
+	if quarry == nil {
+		return Failure
+	}
+	if self.Reaches(quarry.Tile) {
+		self.MovementTarget, self.Path = nil, nil
+		return Success
+	}
+	moved := len(self.Path) > 0 &&
+		quarry.Tile.SpaceComponent.Position.PointDistance(self.Path[len(self.Path)-1]) > float32(config.SpriteWidth)
+	if self.MovementTarget != quarry.Tile || moved {
+		self.MovementTarget, self.Path = nil, nil
+		self.LookFor(self.Perception, func(aabb engo.AABBer) bool {
+			return aabb == engo.AABBer(quarry.Tile)
+		})
+		if self.MovementTarget == nil {
+			// No way there
+			return Failure
+		}
+	}
+	if self.Activity != Hunting {
+		log.Println(self, "hunts", quarry)
+		self.Activity = Hunting
+	}
+	return Running
+}
+
+// kill pounces on the quarry within reach, its carcass becomes the target
+func (self *Creature) kill() Status {
+	quarry := self.Quarry
+	if quarry == nil || !quarry.IsAlive || !self.Reaches(quarry.Tile) {
+		return Failure
+	}
+	log.Println(self, "kills", quarry)
+	quarry.Die(Predation)
+	self.Quarry = nil
+	self.MovementTarget, self.Path = nil, nil
+	self.Target = quarry.Tile
+	return Success
+}
+
+// flee runs from the danger to a spot farther from it, as far as the radius
+// goes. It succeeds while the creature is on its way there.
+func (self *Creature) flee(radius float32) Status {
+	from, _, ok := self.Danger()
+	if !ok {
+		return Failure
+	}
+	position := self.Tile.SpaceComponent.Position
+	distance := position.PointDistance(from)
+	safer := func(aabb engo.AABBer) bool {
+		tile, ok := aabb.(*Tile)
+		return ok && self.FindRestingSpot(tile) && tile.SpaceComponent.Position.PointDistance(from) > distance
+	}
+	if self.MovementTarget != nil && safer(self.MovementTarget) {
+		return Success
+	}
+
+	// Straight away from the threat, or any way if it's right on top
+	away := engo.Point{position.X - from.X, position.Y - from.Y}
+	length := float32(math.Hypot(float64(away.X), float64(away.Y)))
+	if length == 0 {
+		away, length = engo.Point{1, 0}, 1
+	}
+	away.MultiplyScalar(radius * float32(config.SpriteWidth) / length)
+	away.Add(position)
+
+	self.MovementTarget, self.Path = nil, nil
+	self.LastEventID++
+	engo.Mailbox.Dispatch(messages.SpacialRequestMessage{
+		Aabb:     self.SurroundingAreaAABB(radius),
+		Filter:   safer,
+		From:     &away,
+		EntityID: self.BasicEntity.ID(),
+		EventID:  self.LastEventID,
+	})
+	if self.MovementTarget == nil {
+		// Cornered
+		return Failure
+	}
+	if self.Activity != Fleeing {
+		log.Println(self, "flees from", from)
+		self.Activity = Fleeing
+	}
+	return Success
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/calendar"
+	"gogame/messages"
+	"log"
+	"time"
+)
+
+// Day is the length of an in-game day, as the time.Duration ages are kept in
+const Day = time.Duration(calendar.SecondsPerDay) * time.Second
+
+// Causes of death
+const (
+	Starvation = "starvation"
+	OldAge     = "old age"
+	Predation  = "predation"
+)
+
+// Live ages the creature by a second, hurts it while it starves and lets it
+// heal when it's fed. It tells whether the creature survived.
+func (self *Creature) Live() bool {
+	perSecond := 1 / float32(calendar.SecondsPerHour)
+	self.Age += time.Second
+	if self.Food <= 0 {
+		self.Food = 0
+		self.Health -= self.StarvationDamage * perSecond
+	} else if !self.IsHungry() {
+		self.Health += self.HealingRate * perSecond
+		if self.Health > self.MaxHealth {
+			self.Health = self.MaxHealth
+		}
+	}
+
+	if self.Health <= 0 {
+		self.Die(Starvation)
+	} else if self.Lifespan > 0 && self.Age >= time.Duration(self.Lifespan*float32(Day)) {
+		self.Die(OldAge)
+	}
+	return self.IsAlive
+}
+
+// Die stops all behavior of the creature and leaves its corpse behind
+func (self *Creature) Die(cause string) {
+	log.Println(self, "dies of", cause)
+	self.IsAlive = false
+	self.CauseOfDeath = cause
+	self.Activity = Dead
+	self.Intent = nil
+	self.Needs = nil
+	self.Memories = nil
+	self.Target = nil
+	self.MovementTarget = nil
+	self.Path = nil
+	if self.Tile.AnimationComponent != nil {
+		self.Tile.AnimationComponent.CurrentAnimation = nil
+	}
+	if self.CorpseID == 0 {
+		engo.Mailbox.Dispatch(messages.TileRemoveMessage{Entity: self.BasicEntity})
+		return
+	}
+	engo.Mailbox.Dispatch(messages.TileReplaceMessage{
+		Entity:   self.BasicEntity,
+		ObjectID: self.CorpseID,
+	})
+}
+
+// Decay rots the corpse away by a second, until nothing's left to eat
+func (self *Creature) Decay() {
+	resource := self.Tile.AccessibleResource
+	if resource == nil || resource.Amount <= 0 {
+		// Gone already
+		return
+	}
+	resource.Amount -= self.DecayRate / float32(calendar.SecondsPerHour)
+	if resource.Amount <= 0 {
+		log.Println("The corpse of", self, "has rotted away")
+		engo.Mailbox.Dispatch(messages.TileRemoveMessage{Entity: self.BasicEntity})
+	}
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"fmt"
+	"gogame/calendar"
+	"gogame/config"
+)
+
+// Metabolism is what a species burns of its food to live and move around
+type Metabolism struct {
+	BasalMetabolism    float32 `json:"basal_metabolism"`    // Food burnt per hour awake at rest
+	SleepingMetabolism float32 `json:"sleeping_metabolism"` // Share of the basal metabolism burnt asleep
+	EatingCost         float32 `json:"eating_cost"`         // Food burnt per hour eating, on top of the basal metabolism
+	MovementCost       float32 `json:"movement_cost"`       // Food burnt per tile walked
+	SpeedCost          float32 `json:"speed_cost"`          // Food burnt per tile walked for each unit of movement speed
+}
+
+// Food eaten and burnt since the last second
+type energyFlow struct {
+	intake, expenditure float32
+}
+
+// Metabolise burns the food the creature needs for a second of its activity
+// and updates its energy balance with what it ate and burnt in the last one
+func (self *Creature) Metabolise() {
+	cost := self.BasalMetabolism
+	switch self.Activity {
+	case Sleeping:
+		cost *= self.SleepingMetabolism
+	case Eating:
+		cost += self.EatingCost
+	}
+	self.burn(cost / float32(calendar.SecondsPerHour))
+
+	// Averaged over about the last hour
+	perHour := float32(calendar.SecondsPerHour)
+	net := (self.energy.intake - self.energy.expenditure) * perHour
+	self.EnergyBalance += (net - self.EnergyBalance) / perHour
+	self.energy = energyFlow{}
+}
+
+// Feed adds food the creature ate
+func (self *Creature) Feed(amount float32) {
+	self.Food += amount
+	self.energy.intake += amount
+}
+
+// walk burns the food for moving by the distance, in pixels. Moving faster
+// costs more per tile.
+func (self *Creature) walk(distance float32) {
+	tiles := distance / float32(config.SpriteWidth)
+	self.burn(tiles * (self.MovementCost + self.SpeedCost*self.MovementSpeed))
+}
+
+func (self *Creature) burn(amount float32) {
+	self.Food -= amount
+	self.energy.expenditure += amount
+}
+
+// CurrentEnergy is the energy balance of the creature, in food per hour
+func (self *Creature) CurrentEnergy() string {
+	return fmt.Sprintf("energy: %+.1f/h", self.EnergyBalance)
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/messages"
+	"log"
+	"strings"
+)
+
+// Move walks the creature along its path at its movement speed, for `dt`
+// seconds of game time. Once there, the movement target becomes its target.
+func (self *Creature) Move(dt float32) {
+	if self.MovementTarget == nil {
+		self.stopWalking()
+		return
+	}
+	if self.FollowPath(self.MovementSpeed * dt) {
+		log.Println(self, "reached the movement target", self.MovementTarget)
+		self.Target = self.MovementTarget
+		self.MovementTarget = nil
+		self.Path = nil
+		self.stopWalking()
+		return
+	}
+	self.walkAnimation()
+}
+
+// FollowPath moves the creature by the distance, in pixels, past the waypoints
+// and then to the movement target. It tells whether the movement target is
+// reached.
+func (self *Creature) FollowPath(distance float32) bool {
+	position := &self.Tile.SpaceComponent.Position
+	walked := float32(0)
+	reached := false
+	for !reached {
+		next := self.MovementTarget.SpaceComponent.Position
+		if len(self.Path) > 0 {
+			next = self.Path[0]
+		}
+		v := engo.Point{next.X - position.X, next.Y - position.Y}
+		d := length(v)
+		if d > 0 {
+			self.Heading = engo.Point{v.X / d, v.Y / d}
+		}
+		left := distance - walked
+		if d > left {
+			position.Add(*v.MultiplyScalar(left / d))
+			walked = distance
+			break
+		}
+		*position = next
+		walked += d
+		if len(self.Path) > 0 {
+			self.Path = self.Path[1:]
+		} else {
+			reached = true
+		}
+	}
+	if walked > 0 {
+		self.walk(walked)
+		engo.Mailbox.Dispatch(messages.TileMoveMessage{Entity: self.BasicEntity})
+	}
+	return reached
+}
+
+// walkAnimation plays the walk towards the heading, unless it's playing already
+func (self *Creature) walkAnimation() {
+	if self.Tile.AnimationComponent == nil {
+		return
+	}
+	name := "walk_down"
+	switch h := self.Heading; {
+	case h.X*h.X >= h.Y*h.Y && h.X > 0:
+		name = "walk_right"
+	case h.X*h.X >= h.Y*h.Y && h.X < 0:
+		name = "walk_left"
+	case h.Y < 0:
+		name = "walk_up"
+	}
+	current := self.Tile.AnimationComponent.CurrentAnimation
+	if current == nil || current.Name != name {
+		self.Tile.SelectAnimationByName(name)
+	}
+}
+
+// stopWalking stands the creature still once it stops walking
+func (self *Creature) stopWalking() {
+	if self.Tile.AnimationComponent == nil {
+		return
+	}
+	current := self.Tile.AnimationComponent.CurrentAnimation
+	if current != nil && strings.HasPrefix(current.Name, "walk_") {
+		self.Tile.AnimationComponent.CurrentAnimation = nil
+	}
+}
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"gogame/calendar"
+)
+
+func init() {
+	RegisterBehavior(Food, "food", &foodBehavior{})
+	RegisterBehavior(Sleep, "sleep", &sleepBehavior{})
+	RegisterBehavior(Breed, "breed", &breedBehavior{})
+	RegisterBehavior(Safety, "safety", &safetyBehavior{})
+}
+
+// Urgency grows with the time a need goes unsatisfied, by this much per hour
+// up to the maximum
+const (
+	urgencyPerHour = 0.1
+	maxWaitUrgency = 0.5
+)
+
+// deficit is the missing share of a quantity, from 0 to 1
+func deficit(value, max float32) float32 {
+	if value <= 0 || max <= 0 {
+		return 1
+	}
+	if value >= max {
+		return 0
+	}
+	return 1 - value/max
+}
+
+// waitUrgency is the urgency a need gains while it goes unsatisfied
+func waitUrgency(need *Need) float32 {
+	if need == nil {
+		return 0
+	}
+	urgency := float32(need.Duration.Hours()) * urgencyPerHour
+	if urgency > maxWaitUrgency {
+		return maxWaitUrgency
+	}
+	return urgency
+}
+
+type foodBehavior struct{}
+
+// Urgency of food is the share of the stomach that's empty, from the moment the
+// creature gets hungry until it's full, and grows while it finds nothing
+func (*foodBehavior) Urgency(c *Creature, need *Need, t *calendar.Time) float32 {
+	if c.IsSatiated() || (need == nil && !c.IsHungry()) {
+		return 0
+	}
+	return deficit(c.Food, c.MaxFood) + waitUrgency(need)
+}
+
+type sleepBehavior struct{}
+
+// Urgency of sleep is the share of the rest missing, once the creature gets
+// tired or drowsy. It outweighs most else when the creature is exhausted, or
+// asleep already, as it doesn't wake up easily. A creature sleeping at its
+// favourite time of the day sleeps in, unless something else comes up.
+func (*sleepBehavior) Urgency(c *Creature, need *Need, t *calendar.Time) float32 {
+	if need == nil && !c.IsTired() {
+		return 0
+	}
+	sleepingIn := c.Activity == Sleeping && c.PrefersToSleep(t)
+	if c.IsFullyRested() && !sleepingIn {
+		return 0
+	}
+	score := deficit(c.Sleep, c.MaxSleep)
+	if c.IsTired() || c.Activity == Sleeping {
+		score += 1
+	}
+	return score
+}
+
+type breedBehavior struct{}
+
+// Urgency of breeding is modest, anything pressing comes first
+func (*breedBehavior) Urgency(c *Creature, need *Need, t *calendar.Time) float32 {
+	if !c.CanBreed(t) {
+		return 0
+	}
+	return 0.5
+}
+
+type safetyBehavior struct{}
+
+// Urgency of safety beats anything else while a predator is in sight, and
+// fades with the memory of one nearby
+func (*safetyBehavior) Urgency(c *Creature, need *Need, t *calendar.Time) float32 {
+	_, certainty, ok := c.Danger()
+	if !ok {
+		return 0
+	}
+	return 3 * certainty
+}
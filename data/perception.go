@@ -0,0 +1,193 @@
+package data
+
+import (
+	"fmt"
+	"github.com/EngoEngine/engo"
+	"gogame/calendar"
+	"gogame/config"
+	"gogame/messages"
+	"gogame/terrain"
+	"log"
+	"math"
+)
+
+// MemoryKind is what a creature remembers
+type MemoryKind uint8
+
+const (
+	FoodMemory MemoryKind = iota
+	WaterMemory
+	ThreatMemory
+)
+
+// Memories of each kind a creature keeps at most, it forgets the faintest ones
+const maxMemories = 5
+
+func (k MemoryKind) String() string {
+	return [...]string{"food", "water", "threat"}[k]
+}
+
+// Memory of something the creature has seen, it fades away over time
+type Memory struct {
+	Kind     MemoryKind `json:"kind"`
+	EntityID uint64     `json:"entity_id"`
+	Position engo.Point `json:"position"`
+	Strength float32    `json:"strength"` // 1 when just seen, forgotten at 0
+}
+
+// Sees tells whether the point is within the perception of the creature, and
+// in its field of view around the way it's heading if it has a limited one
+func (self *Creature) Sees(p engo.Point) bool {
+	position := self.Tile.SpaceComponent.Position
+	v := engo.Point{p.X - position.X, p.Y - position.Y}
+	d := length(v)
+	if d > self.Perception*float32(config.SpriteWidth) {
+		return false
+	}
+	if d == 0 || self.FieldOfView <= 0 || self.FieldOfView >= 360 || length(self.Heading) == 0 {
+		return true
+	}
+	cos := (v.X*self.Heading.X + v.Y*self.Heading.Y) / d
+	return float64(cos) >= math.Cos(float64(self.FieldOfView)/2*math.Pi/180)
+}
+
+// FindVisibleFood accepts unclaimed food in sight of the creature
+func (self *Creature) FindVisibleFood(x engo.AABBer) bool {
+	tile, ok := x.(*Tile)
+	return ok && self.Sees(tile.SpaceComponent.Position) && self.FindUnclaimedFood(x)
+}
+
+// IsWater tells whether the tile is water shallow enough to drink
+func (self *Tile) IsWater() bool {
+	return self.Layer == 0 && self.Object != nil && self.Object.Terrain == terrain.Water
+}
+
+// Remember refreshes the memory of the tile, or makes a new one in place of the
+// faintest memory of the kind
+func (self *Creature) Remember(kind MemoryKind, tile *Tile) {
+	position := tile.SpaceComponent.Position
+	var faintest *Memory
+	count := 0
+	for _, m := range self.Memories {
+		if m.Kind != kind {
+			continue
+		}
+		if m.EntityID == tile.BasicEntity.ID() || m.Position == position {
+			m.EntityID, m.Position, m.Strength = tile.BasicEntity.ID(), position, 1
+			return
+		}
+		count++
+		if faintest == nil || m.Strength < faintest.Strength {
+			faintest = m
+		}
+	}
+	if count >= maxMemories {
+		self.Forget(faintest)
+	}
+	self.Memories = append(self.Memories, &Memory{kind, tile.BasicEntity.ID(), position, 1})
+}
+
+// Forget drops the memory
+func (self *Creature) Forget(memory *Memory) {
+	for i, m := range self.Memories {
+		if m == memory {
+			self.Memories = append(self.Memories[:i], self.Memories[i+1:]...)
+			return
+		}
+	}
+}
+
+// ForgetGoneFood drops the memories of food the creature sees isn't there any
+// longer, the positions of all the food in sight are given
+func (self *Creature) ForgetGoneFood(seen map[engo.Point]bool) {
+	var kept []*Memory
+	for _, m := range self.Memories {
+		if m.Kind != FoodMemory || seen[m.Position] || !self.Sees(m.Position) {
+			kept = append(kept, m)
+		}
+	}
+	self.Memories = kept
+}
+
+// Fade weakens the memories by a second's worth, forgetting the faded ones
+func (self *Creature) Fade() {
+	var kept []*Memory
+	for _, m := range self.Memories {
+		m.Strength -= self.MemoryDecay / float32(calendar.SecondsPerHour)
+		if m.Strength > 0 {
+			kept = append(kept, m)
+		}
+	}
+	self.Memories = kept
+}
+
+// Recall returns the strongest memory of the kind, nil if there's none
+func (self *Creature) Recall(kind MemoryKind) *Memory {
+	var result *Memory
+	for _, m := range self.Memories {
+		if m.Kind == kind && (result == nil || m.Strength > result.Strength) {
+			result = m
+		}
+	}
+	return result
+}
+
+// Danger is where the creature sees a predator, or remembers one nearby
+func (self *Creature) Danger() (engo.Point, float32, bool) {
+	if self.Threat != nil {
+		return self.Threat.Tile.SpaceComponent.Position, 1, true
+	}
+	memory := self.Recall(ThreatMemory)
+	if memory == nil {
+		return engo.Point{}, 0, false
+	}
+	position := self.Tile.SpaceComponent.Position
+	if position.PointDistance(memory.Position) > self.Perception*float32(config.SpriteWidth) {
+		// Far enough
+		return engo.Point{}, 0, false
+	}
+	return memory.Position, memory.Strength, true
+}
+
+// recallFood heads for the food the creature remembers best. It succeeds once
+// the creature is on its way there, and forgets food that's gone.
+func (self *Creature) recallFood() Status {
+	if self.MovementTarget != nil {
+		if self.FindFood(self.MovementTarget) {
+			return Success
+		}
+		return Failure
+	}
+	memory := self.Recall(FoodMemory)
+	if memory == nil {
+		return Failure
+	}
+	position := memory.Position
+	self.LastEventID++
+	engo.Mailbox.Dispatch(messages.SpacialRequestMessage{
+		Aabb: engo.AABB{
+			Min: engo.Point{position.X, position.Y},
+			Max: engo.Point{position.X + float32(config.SpriteWidth), position.Y + float32(config.SpriteHeight)},
+		},
+		Filter:   self.FindUnclaimedFood,
+		From:     &position,
+		EntityID: self.BasicEntity.ID(),
+		EventID:  self.LastEventID,
+	})
+	if self.MovementTarget == nil {
+		self.Forget(memory)
+		return Failure
+	}
+	log.Println(self, "remembers food at", position)
+	return Success
+}
+
+// CurrentMemories sums up what the creature remembers
+func (self *Creature) CurrentMemories() string {
+	count := make(map[MemoryKind]int)
+	for _, m := range self.Memories {
+		count[m.Kind]++
+	}
+	return fmt.Sprintf("Remembers %d food, %d water, %d threats",
+		count[FoodMemory], count[WaterMemory], count[ThreatMemory])
+}
@@ -0,0 +1,48 @@
+package data
+
+// Soil of a ground tile, both fields range from 0 to 1
+type Soil struct {
+	X         int     `json:"x"`
+	Y         int     `json:"y"`
+	Fertility float32 `json:"fertility"` // Nutrients plants grow on
+	Moisture  float32 `json:"moisture"`
+}
+
+// Plants grow at full speed on soil at least this fertile and moist, and
+// slower the poorer or the drier it is
+const richSoil = 0.5
+
+// GrowthFactor is the share of their growth plants make on the soil
+func (self *Soil) GrowthFactor() float32 {
+	fertility, moisture := self.Fertility/richSoil, self.Moisture/richSoil
+	if fertility > 1 {
+		fertility = 1
+	}
+	if moisture > 1 {
+		moisture = 1
+	}
+	return fertility * moisture
+}
+
+// Deplete takes up the nutrients and the water, as much as there is
+func (self *Soil) Deplete(nutrients, water float32) {
+	self.Fertility = clamp(self.Fertility - nutrients)
+	self.Moisture = clamp(self.Moisture - water)
+}
+
+// Enrich adds to the nutrients and the water, up to the most the soil holds
+func (self *Soil) Enrich(nutrients, water float32) {
+	self.Fertility = clamp(self.Fertility + nutrients)
+	self.Moisture = clamp(self.Moisture + water)
+}
+
+// clamp keeps the value within 0 and 1
+func clamp(value float32) float32 {
+	if value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return value
+}
@@ -0,0 +1,230 @@
+package data
+
+import (
+	"fmt"
+	"gogame/calendar"
+	"math/rand"
+)
+
+// Status is the outcome of ticking a node of a behavior tree
+type Status uint8
+
+const (
+	Success Status = iota
+	Failure
+	Running
+)
+
+func (s Status) String() string {
+	return [...]string{"success", "failure", "running"}[s]
+}
+
+// Node of a behavior tree. Nodes keep no state of their own, everything a
+// creature is up to is stored in the creature, so a tree is shared by all the
+// creatures of a species and survives saving.
+type Node interface {
+	Tick(c *Creature, t *calendar.Time, r *rand.Rand) Status
+}
+
+// NodeDef describes a node of a behavior tree, as read from behaviors.json:
+//
+//	{"type": "sequence", "children": [
+//	    {"type": "condition", "name": "intends", "params": {"want": "food"}},
+//	    {"type": "action", "name": "find_food", "params": {"radius": 2}}
+//	]}
+type NodeDef struct {
+	Type     string                 `json:"type"` // selector, sequence, not, condition or action
+	Name     string                 `json:"name"` // Of the condition or the action
+	Params   map[string]interface{} `json:"params"`
+	Children []*NodeDef             `json:"children"`
+}
+
+// TreeDef is a named behavior tree which species refer to
+type TreeDef struct {
+	Name string   `json:"name"`
+	Root *NodeDef `json:"root"`
+}
+
+type TreeDefs struct {
+	Trees []*TreeDef `json:"trees"`
+}
+
+// BehaviorTree decides what a creature does, once its most urgent want is known
+type BehaviorTree struct {
+	Name string
+	Root Node
+}
+
+func (self *BehaviorTree) Tick(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+	return self.Root.Tick(c, t, r)
+}
+
+// Condition tells whether something holds for the creature
+type Condition func(c *Creature, t *calendar.Time, r *rand.Rand) bool
+
+// ActionFunc makes the creature do something, or a step of it
+type ActionFunc func(c *Creature, t *calendar.Time, r *rand.Rand) Status
+
+var (
+	conditions = make(map[string]func(params *Params) (Condition, error))
+	actions    = make(map[string]func(params *Params) (ActionFunc, error))
+)
+
+// RegisterCondition makes a condition available to behavior trees. The builder
+// reads the parameters of the node, if the condition takes any.
+func RegisterCondition(name string, build func(params *Params) (Condition, error)) {
+	conditions[name] = build
+}
+
+// RegisterAction makes an action available to behavior trees. The builder
+// reads the parameters of the node, if the action takes any.
+func RegisterAction(name string, build func(params *Params) (ActionFunc, error)) {
+	actions[name] = build
+}
+
+// Params of a node of a behavior tree
+type Params struct {
+	values map[string]interface{}
+}
+
+// Float returns a numeric parameter, or the default if it's not set
+func (self *Params) Float(name string, def float32) (float32, error) {
+	v, ok := self.values[name]
+	if !ok {
+		return def, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("parameter '%s' must be a number, got %v", name, v)
+	}
+	return float32(f), nil
+}
+
+// String returns a required text parameter
+func (self *Params) String(name string) (string, error) {
+	v, ok := self.values[name]
+	if !ok {
+		return "", fmt.Errorf("parameter '%s' is missing", name)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("parameter '%s' must be a text, got %v", name, v)
+	}
+	return s, nil
+}
+
+// BuildTree turns a definition into a behavior tree made of the registered
+// conditions and actions
+func BuildTree(def *TreeDef) (*BehaviorTree, error) {
+	if def.Root == nil {
+		return nil, fmt.Errorf("behavior tree '%s' has no root", def.Name)
+	}
+	root, err := buildNode(def.Root)
+	if err != nil {
+		return nil, fmt.Errorf("behavior tree '%s': %s", def.Name, err)
+	}
+	return &BehaviorTree{Name: def.Name, Root: root}, nil
+}
+
+func buildNode(def *NodeDef) (Node, error) {
+	params := &Params{def.Params}
+	switch def.Type {
+	case "selector", "sequence":
+		if len(def.Children) == 0 {
+			return nil, fmt.Errorf("%s without children", def.Type)
+		}
+		var children []Node
+		for _, c := range def.Children {
+			child, err := buildNode(c)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		if def.Type == "selector" {
+			return selector(children), nil
+		}
+		return sequence(children), nil
+	case "not":
+		if len(def.Children) != 1 {
+			return nil, fmt.Errorf("not takes a single child, got %d", len(def.Children))
+		}
+		child, err := buildNode(def.Children[0])
+		if err != nil {
+			return nil, err
+		}
+		return &inverter{child}, nil
+	case "condition":
+		build, ok := conditions[def.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown condition '%s'", def.Name)
+		}
+		condition, err := build(params)
+		if err != nil {
+			return nil, fmt.Errorf("condition '%s': %s", def.Name, err)
+		}
+		return condition, nil
+	case "action":
+		build, ok := actions[def.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown action '%s'", def.Name)
+		}
+		action, err := build(params)
+		if err != nil {
+			return nil, fmt.Errorf("action '%s': %s", def.Name, err)
+		}
+		return action, nil
+	}
+	return nil, fmt.Errorf("unknown node type '%s'", def.Type)
+}
+
+// selector ticks its children in order until one of them doesn't fail
+type selector []Node
+
+func (self selector) Tick(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+	for _, child := range self {
+		if status := child.Tick(c, t, r); status != Failure {
+			return status
+		}
+	}
+	return Failure
+}
+
+// sequence ticks its children in order as long as they succeed
+type sequence []Node
+
+func (self sequence) Tick(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+	for _, child := range self {
+		if status := child.Tick(c, t, r); status != Success {
+			return status
+		}
+	}
+	return Success
+}
+
+// inverter swaps the success and the failure of its child
+type inverter struct {
+	child Node
+}
+
+func (self *inverter) Tick(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+	switch status := self.child.Tick(c, t, r); status {
+	case Success:
+		return Failure
+	case Failure:
+		return Success
+	default:
+		return status
+	}
+}
+
+func (self Condition) Tick(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+	if self(c, t, r) {
+		return Success
+	}
+	return Failure
+}
+
+func (self ActionFunc) Tick(c *Creature, t *calendar.Time, r *rand.Rand) Status {
+	return self(c, t, r)
+}
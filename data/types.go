@@ -43,6 +43,9 @@ type Object struct {
 	Name          string  `json:"name"`
 	ResourceID    int     `json:"resource_id"`
 	Amount        float32 `json:"amount"`
+	Terrain       string  `json:"terrain"`       // Terrain type of a ground object, as named in the tileset
+	Impassable    bool    `json:"impassable"`    // Nothing can walk through or over it
+	MovementCost  float32 `json:"movement_cost"` // Of walking over a ground object, 1 if not set
 
 	// Runtime only fields
 	Spritesheet *common.Spritesheet `json:"-"`
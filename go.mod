@@ -6,6 +6,7 @@ require (
 	github.com/EngoEngine/ecs v1.0.4
 	github.com/EngoEngine/engo v1.0.6-0.20200813134554-7c650958219e
 	github.com/EngoEngine/gl v1.0.11
+	github.com/Noofbiz/tmx v0.2.0
 	github.com/g3n/engine v0.1.1-0.20200214161420-db7282a2ba23
 	github.com/g3n/g3nd v0.1.0
 	github.com/go-gl/glfw v0.0.0-20200625191551-73d3c3675aa3 // indirect
@@ -15,3 +16,5 @@ require (
 	golang.org/x/image v0.0.0-20200618115811-c13761719519
 	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
+
+replace github.com/hajimehoshi/oto => ./third_party/oto
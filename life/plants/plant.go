@@ -9,6 +9,7 @@ import (
 	"gogame/calendar"
 	"gogame/data"
 	"gogame/messages"
+	"image/color"
 )
 
 var (
@@ -16,6 +17,9 @@ var (
 	PlantById map[int]*Plant
 )
 
+// Tint of plants which don't grow at all
+var withered = color.RGBA{190, 140, 80, 255}
+
 type Activity uint8
 
 const (
@@ -30,6 +34,10 @@ func (a Activity) String() string {
 
 type Plant struct {
 	*data.Tile `deepcopier:"skip"`
+	// Soil it grows on, if any
+	Soil *data.Soil `json:"-" deepcopier:"skip"`
+	// Biomass of the neighbours shading it, as of the last hour
+	Shade float32 `json:"-" deepcopier:"skip"`
 
 	// Species properties, immutable
 	ID          int     `json:"id"`
@@ -40,11 +48,44 @@ type Plant struct {
 	GrowthRate  float32 `json:"growth_rate"`
 	GrowthSpeed float32 `json:"growth_speed"`
 	MaxGrowth   float32 `json:"max_growth"`
+	DeadID      int     `json:"dead_id"`    // Plant it turns into when it dies, it's gone if 0
+	Senescence  float32 `json:"senescence"` // Hours it lives once fully grown, forever if 0
+	DecayRate   float32 `json:"decay_rate"` // Growth lost per hour once dead
+	// Fertility and moisture of the soil taken up per point of growth
+	NutrientUptake float32 `json:"nutrient_uptake"`
+	WaterUptake    float32 `json:"water_uptake"`
+	// Multipliers of the growth by the name of the season, 1 if not set. It's
+	// dormant in a season without growth.
+	SeasonalGrowth map[string]float32 `json:"seasonal_growth"`
+	Seeding
+	Competition
 
 	// Live properties, mutable
-	IsAlive  bool     `json:"is_alive"`
-	Activity Activity `json:"activity"`
-	Growth   float32  `json:"growth"`
+	IsAlive    bool     `json:"is_alive"`
+	Activity   Activity `json:"activity"`
+	Growth     float32  `json:"growth"`
+	Ripeness   float32  `json:"ripeness"`    // Hours it's been fully grown, not counting its dormancy
+	LastSeeded uint64   `json:"last_seeded"` // In seconds since the beginning of time
+	Nutrients  float32  `json:"nutrients"`   // Taken up from the soil, returned to it once it rots
+}
+
+// Seeding is how a species spreads once fully grown, it doesn't if it scatters
+// no seeds
+type Seeding struct {
+	SeedlingID    int      `json:"seedling_id"`    // Plant the seeds grow into
+	Seeds         int      `json:"seeds"`          // Scattered at once
+	SeedInterval  float32  `json:"seed_interval"`  // Hours between two scatterings
+	SeedRadius    float32  `json:"seed_radius"`    // Tiles the seeds land within
+	Soils         []string `json:"soils"`          // Terrains the seeds take root in
+	MaxNeighbours int      `json:"max_neighbours"` // Plants around a tile beyond which seeds don't take root
+}
+
+// Competition is how a species fares among its neighbours for light and
+// space, it grows regardless of them if it isn't sensitive to crowding
+type Competition struct {
+	CompetitionRadius float32 `json:"competition_radius"` // Tiles the neighbours it competes with are within
+	Crowding          float32 `json:"crowding"`           // Growth slowed per point of biomass shading it
+	ShadeTolerance    float32 `json:"shade_tolerance"`    // Least share of its growth it lives on, crowded out below it
 }
 
 type Plants struct {
@@ -74,26 +115,113 @@ func GetPlantByID(plantID int) *Plant {
 	panic(fmt.Sprintf("Unknown plant '%d'", plantID))
 }
 
-func (self *Plant) GetGrowthSpeed() float32 {
-	// TODO affected by the environment, weather etc.
-	return self.GrowthSpeed
+// GetPlantByObjectID returns the plant drawn as the given object, if any
+func GetPlantByObjectID(objectID int) (*Plant, bool) {
+	if PlantById == nil {
+		initPlants()
+	}
+
+	for _, p := range plants.Plants {
+		if p.ObjectID == objectID {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// monthFactor is the multiplier of the growth of the plant set for the month,
+// by its name or else by its season, 1 if neither is set
+func (self *Plant) monthFactor(month calendar.Month) float32 {
+	if factor, ok := self.SeasonalGrowth[month.String()]; ok {
+		return factor
+	}
+	if factor, ok := self.SeasonalGrowth[month.Season().String()]; ok {
+		return factor
+	}
+	return 1
+}
+
+// SeasonFactor is the multiplier of the growth of the plant at the time of the
+// year. It's the one of the month at its start, and changes gradually into
+// the one of the next month.
+func (self *Plant) SeasonFactor(t *calendar.Time) float32 {
+	this, next := self.monthFactor(t.Month), self.monthFactor(t.Month.Next())
+	return this + (next-this)*t.MonthProgress()
+}
+
+// Tint colours the plant by how it grows at the time of the year, its own
+// green at full growth and browner the slower it grows
+func (self *Plant) Tint(t *calendar.Time) color.RGBA {
+	factor := self.SeasonFactor(t)
+	if factor > 1 {
+		factor = 1
+	} else if factor < 0 {
+		factor = 0
+	}
+	shade := func(brown uint8) uint8 {
+		return brown + uint8(float32(255-brown)*factor)
+	}
+	return color.RGBA{shade(withered.R), shade(withered.G), shade(withered.B), 255}
+}
+
+// SoilFactor is the multiplier of the growth of the plant on its soil
+func (self *Plant) SoilFactor() float32 {
+	if self.Soil == nil {
+		return 1
+	}
+	return self.Soil.GrowthFactor()
+}
+
+// Shading is how much of the biomass of the neighbour shades the plant, the
+// more the larger the neighbour is than the plant
+func (self *Plant) Shading(neighbour *Plant) float32 {
+	if neighbour.Growth <= 0 {
+		return 0
+	}
+	return neighbour.Growth * neighbour.Growth / (neighbour.Growth + self.Growth)
+}
+
+// CompetitionFactor is the multiplier of the growth of the plant among its
+// neighbours
+func (self *Plant) CompetitionFactor() float32 {
+	return 1 / (1 + self.Crowding*self.Shade)
+}
+
+// CrowdedOut tells whether the neighbours leave the plant too little light
+// and space to live on
+func (self *Plant) CrowdedOut() bool {
+	return self.ShadeTolerance > 0 && self.CompetitionFactor() < self.ShadeTolerance
 }
 
-func (self *Plant) GetGrowthRate() float32 {
-	// TODO affected by the environment, weather etc.
-	return self.GrowthRate
+func (self *Plant) GetGrowthSpeed(t *calendar.Time) float32 {
+	// TODO affected by the weather etc.
+	return self.GrowthSpeed * self.SeasonFactor(t) * self.SoilFactor() * self.CompetitionFactor()
+}
+
+func (self *Plant) GetGrowthRate(t *calendar.Time) float32 {
+	// TODO affected by the weather etc.
+	return self.GrowthRate * self.SeasonFactor(t) * self.SoilFactor() * self.CompetitionFactor()
 }
 
 func (self *Plant) IsFullyGrown() bool {
 	return self.Growth >= self.MaxGrowth
 }
 
+// CanSeed tells whether the plant is due to scatter its seeds
+func (self *Plant) CanSeed(t *calendar.Time) bool {
+	if !self.IsAlive || self.Seeds <= 0 || self.SeedlingID == 0 || self.LastSeeded == 0 {
+		return false
+	}
+	interval := uint64(self.SeedInterval * float32(calendar.SecondsPerHour))
+	return t.SecondsSinceBeginningOfTime >= self.LastSeeded+interval
+}
+
 func (self *Plant) Mature() {
 	// Replace with the mature plant or a new growth stage
 	newPlant := GetPlantByID(self.GrownID)
-	oldGrowth := self.Growth
+	oldGrowth, nutrients := self.Growth, self.Nutrients
 	deepcopier.Copy(newPlant).To(self)
-	self.Growth = oldGrowth
+	self.Growth, self.Nutrients = oldGrowth, nutrients
 	self.MaxGrowth += oldGrowth
 
 	// Update plant's visual representation
@@ -104,10 +232,14 @@ func (self *Plant) Mature() {
 }
 
 func (self *Plant) CurrentGrowth() string {
-	return fmt.Sprintf(
+	growth := fmt.Sprintf(
 		"Growth: %d/%d",
 		int(self.Growth), int(self.MaxGrowth),
 	)
+	if self.Shade > 0 {
+		growth += fmt.Sprintf(", %d%% in the shade", int(self.CompetitionFactor()*100))
+	}
+	return growth
 }
 
 func (self *Plant) CurrentPosition() string {
@@ -123,20 +255,111 @@ func (self *Plant) GetTextStatus() string {
 	)
 }
 
+// Dormant tells whether the plant doesn't grow at all at the time of the year
+func (self *Plant) Dormant(t *calendar.Time) bool {
+	return self.SeasonFactor(t) <= 0
+}
+
+// Rests tells whether the plant stops growing at the time, at night and while
+// dormant
+func (self *Plant) Rests(t *calendar.Time) bool {
+	return t.IsNight() || self.Dormant(t)
+}
+
+// Senesced tells whether the plant has lived out its time since it got fully grown
+func (self *Plant) Senesced() bool {
+	return self.Senescence > 0 && self.Ripeness >= self.Senescence
+}
+
+// Die turns the plant into its dead variant, or removes it if it has none
+func (self *Plant) Die() {
+	if self.DeadID == 0 {
+		self.rot()
+		return
+	}
+	// Keeps what it has grown, and rots away from there
+	dead := GetPlantByID(self.DeadID)
+	growth, nutrients := self.Growth, self.Nutrients
+	ripeness, lastSeeded := self.Ripeness, self.LastSeeded
+	deepcopier.Copy(dead).To(self)
+	self.Growth, self.MaxGrowth, self.Nutrients = growth, growth, nutrients
+	self.Ripeness, self.LastSeeded = ripeness, lastSeeded
+	self.Activity = Dead
+	engo.Mailbox.Dispatch(messages.TileReplaceMessage{
+		Entity:   self.Tile.BasicEntity,
+		ObjectID: dead.ObjectID,
+	})
+}
+
+// Decay rots the dead plant away by a second, until nothing's left of it
+func (self *Plant) Decay() {
+	self.Growth -= self.DecayRate / float32(calendar.SecondsPerHour)
+	if self.Growth <= 0 {
+		self.rot()
+	}
+}
+
+// rot removes the plant and returns what it was made of to the soil
+func (self *Plant) rot() {
+	engo.Mailbox.Dispatch(messages.NutrientsMessage{
+		Point:  self.Tile.SpaceComponent.Position,
+		Amount: self.Nutrients,
+	})
+	engo.Mailbox.Dispatch(messages.TileRemoveMessage{
+		Entity: self.Tile.BasicEntity,
+	})
+}
+
 func (self *Plant) Update(currentTime *calendar.Time) {
 	if !self.IsAlive {
 		self.Activity = Dead
+		self.Decay()
+		return
 	}
+	if self.IsFullyGrown() && self.GrownID == 0 {
+		if self.LastSeeded == 0 {
+			// Scatters its first seeds an interval after it's grown
+			self.LastSeeded = currentTime.SecondsSinceBeginningOfTime
+		}
+		if !self.Dormant(currentTime) {
+			// Lives through the seasons it's dormant in
+			self.Ripeness += 1 / float32(calendar.SecondsPerHour)
+		}
+		if self.Senesced() {
+			self.Die()
+			return
+		}
+	}
+	if self.Rests(currentTime) {
+		self.Activity = Resting
+		return
+	}
+	if self.CrowdedOut() {
+		self.Die()
+		return
+	}
+	self.Activity = Growing
 	if self.IsFullyGrown() {
 		if self.GrownID != 0 {
 			self.Mature()
-		} else {
-			// TODO should eventually die
+		} else if self.CanSeed(currentTime) {
+			engo.Mailbox.Dispatch(messages.SeedMessage{
+				EntityID: self.Tile.BasicEntity.ID(),
+				Time:     currentTime,
+			})
 		}
-	} else if self.Activity == Growing {
+	} else {
 		// Handle growth
-		self.Growth += self.GetGrowthSpeed()
-		self.Tile.AccessibleResource.Amount += self.GetGrowthRate()
+		growth := self.GetGrowthSpeed(currentTime)
+		self.Growth += growth
+		self.Tile.AccessibleResource.Amount += self.GetGrowthRate(currentTime)
+		if self.Soil != nil {
+			nutrients := growth * self.NutrientUptake
+			if nutrients > self.Soil.Fertility {
+				nutrients = self.Soil.Fertility
+			}
+			self.Nutrients += nutrients
+			self.Soil.Deplete(nutrients, growth*self.WaterUptake)
+		}
 	}
-	// Handle rest TODO
 }
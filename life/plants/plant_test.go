@@ -0,0 +1,142 @@
+package plants
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"gogame/calendar"
+	"gogame/data"
+	"testing"
+)
+
+func TestShading(t *testing.T) {
+	tests := []struct {
+		name              string
+		growth, neighbour float32
+		want              float32
+	}{
+		{"no neighbour growth", 100, 0, 0},
+		{"equal neighbour", 100, 100, 50},
+		{"larger neighbour", 100, 300, 225},
+		{"smaller neighbour", 300, 100, 25},
+		{"over a seedling", 0, 100, 100},
+	}
+	for _, test := range tests {
+		plant, neighbour := &Plant{Growth: test.growth}, &Plant{Growth: test.neighbour}
+		if got := plant.Shading(neighbour); got != test.want {
+			t.Errorf("%s: shading is %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	plant := &Plant{Growth: 100}
+	if larger, smaller := plant.Shading(&Plant{Growth: 200}), plant.Shading(&Plant{Growth: 50}); larger <= smaller {
+		t.Errorf("a larger neighbour shades %v, no more than a smaller one with %v", larger, smaller)
+	}
+}
+
+func TestCompetitionFactor(t *testing.T) {
+	tests := []struct {
+		name            string
+		crowding, shade float32
+		want            float32
+	}{
+		{"no shade", 0.002, 0, 1},
+		{"not sensitive", 0, 1000, 1},
+		{"shaded", 0.002, 500, 0.5},
+		{"deep shade", 0.002, 1500, 0.25},
+	}
+	for _, test := range tests {
+		plant := &Plant{Shade: test.shade}
+		plant.Crowding = test.crowding
+		if got := plant.CompetitionFactor(); got != test.want {
+			t.Errorf("%s: competition factor is %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCrowdedOut(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance float32
+		shade     float32
+		want      bool
+	}{
+		{"above the tolerance", 0.25, 500, false},
+		{"at the tolerance", 0.25, 1500, false},
+		{"below the tolerance", 0.25, 2000, true},
+		{"no tolerance", 0, 100000, false},
+	}
+	for _, test := range tests {
+		plant := &Plant{Shade: test.shade}
+		plant.Crowding, plant.ShadeTolerance = 0.002, test.tolerance
+		if got := plant.CrowdedOut(); got != test.want {
+			t.Errorf("%s: crowded out is %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDieKeepsGrowth(t *testing.T) {
+	engo.Mailbox = &engo.MessageManager{}
+	species := PlantById
+	defer func() { PlantById = species }()
+	PlantById = map[int]*Plant{
+		5: {ID: 5, ObjectID: 5, Name: "Dead meadow grass", Growth: 200, MaxGrowth: 200, DecayRate: 10},
+	}
+
+	basic := ecs.NewBasic()
+	plant := &Plant{
+		Tile:       &data.Tile{BasicEntity: &basic},
+		ID:         3,
+		Name:       "Flowering meadow grass",
+		DeadID:     5,
+		IsAlive:    true,
+		Growth:     263,
+		MaxGrowth:  250,
+		Ripeness:   72,
+		LastSeeded: 3600,
+		Nutrients:  0.25,
+	}
+	plant.Die()
+
+	if plant.ID != 5 || plant.IsAlive || plant.Activity != Dead || plant.DecayRate != 10 {
+		t.Errorf("the plant didn't turn into the dead one: %+v", plant)
+	}
+	if plant.Growth != 263 {
+		t.Errorf("the dead plant has %v growth, want the 263 it had grown", plant.Growth)
+	}
+	if plant.Nutrients != 0.25 || plant.Ripeness != 72 || plant.LastSeeded != 3600 {
+		t.Errorf("the dead plant lost its state: nutrients %v, ripeness %v, last seeded %v",
+			plant.Nutrients, plant.Ripeness, plant.LastSeeded)
+	}
+}
+
+func TestSeasonFactor(t *testing.T) {
+	plant := &Plant{SeasonalGrowth: map[string]float32{"Greencrest": 1.5, "spring": 1.2, "autumn": 0.5, "winter": 0}}
+	tests := []struct {
+		name string
+		time calendar.Time
+		want float32
+	}{
+		{"season at the start of the month", calendar.Time{Month: calendar.Lightwake}, 1.2},
+		{"month over its season", calendar.Time{Month: calendar.Greencrest}, 1.5},
+		{"halfway into the next month", calendar.Time{Month: calendar.Lightwake, Day: 15}, 1.35},
+		{"season left out", calendar.Time{Month: calendar.Solarcrest}, 1},
+		{"into the winter", calendar.Time{Month: calendar.Stormreach, Day: 15}, 0.25},
+		{"winter", calendar.Time{Month: calendar.Icewane, Day: 15}, 0},
+		{"over the year", calendar.Time{Month: calendar.Whitereign, Day: 15}, 0.6},
+	}
+	for _, test := range tests {
+		if got := plant.SeasonFactor(&test.time); got < test.want-0.001 || got > test.want+0.001 {
+			t.Errorf("%s: the factor is %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	if plant.Dormant(&calendar.Time{Month: calendar.Stormreach, Day: 29}) {
+		t.Error("the plant is dormant before the winter")
+	}
+	if !plant.Dormant(&calendar.Time{Month: calendar.Icewane}) {
+		t.Error("the plant isn't dormant in the winter")
+	}
+	if green, brown := plant.Tint(&calendar.Time{Month: calendar.Greencrest}), plant.Tint(&calendar.Time{Month: calendar.Icewane}); green.G != 255 || brown.G >= green.G {
+		t.Errorf("the plant is tinted %v at its peak and %v in the winter", green, brown)
+	}
+}
@@ -2,16 +2,20 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/EngoEngine/ecs"
 	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/common"
 	"gogame/assets"
+	"gogame/config"
 	"gogame/controls"
 	"gogame/messages"
 	"gogame/save"
 	"gogame/shaders"
 	"gogame/systems"
+	"gogame/tiled"
+	"gogame/util"
 	"image/color"
 	"log"
 	"os"
@@ -23,6 +27,11 @@ var (
 
 	worldWidth  int = 800
 	worldHeight int = 800
+
+	seed    = flag.Int64("seed", 0, "world seed, a new random world is generated each time if not set")
+	mapPath = flag.String("map", "", "Tiled map (.tmx) to start from instead of a generated world")
+	width   = flag.Int("width", config.WorldWidth, "width of a generated world, in tiles")
+	height  = flag.Int("height", config.WorldHeight, "height of a generated world, in tiles")
 )
 
 type myScene struct{}
@@ -62,15 +71,18 @@ func (self *myScene) Setup(u engo.Updater) {
 	engo.Input.RegisterButton("TogglePause", engo.KeySpace)
 	engo.Input.RegisterButton("AddCreature", engo.KeyF1)
 	engo.Input.RegisterButton("AddObject", engo.KeyF2)
+	engo.Input.RegisterButton("AddPredator", engo.KeyF3)
 	engo.Input.RegisterButton("NewWorld", engo.KeyF4)
 	engo.Input.RegisterButton("QuickSave", engo.KeyF5)
 	engo.Input.RegisterButton("QuickLoad", engo.KeyF6)
+	engo.Input.RegisterButton("ExportMap", engo.KeyF7)
+	engo.Input.RegisterButton("SoilOverlay", engo.KeyF8)
 	engo.Input.RegisterButton("ExitToDesktop", engo.KeyEscape)
 
 	// Visual debug
 	world.AddSystem(&systems.DebugSystem{})
 
-	// Spacial (quadtree, pathfinding TODO etc)
+	// Spacial (quadtree, pathfinding etc)
 	world.AddSystem(&systems.SpacialSystem{})
 
 	// Controls
@@ -78,6 +90,7 @@ func (self *myScene) Setup(u engo.Updater) {
 
 	// World
 	world.AddSystem(&systems.WorldTilesSystem{})
+	world.AddSystem(&systems.SoilSystem{})
 
 	// HUD
 	world.AddSystem(&systems.HUDSystem{})
@@ -105,6 +118,14 @@ func (self *myScene) Setup(u engo.Updater) {
 		}
 		HandleLoadMessage(world, msg.Filepath)
 	})
+	engo.Mailbox.Listen(messages.ExportMessageType, func(m engo.Message) {
+		log.Printf("%+v", m)
+		msg, ok := m.(messages.ExportMessage)
+		if !ok {
+			return
+		}
+		HandleExportMessage(world, msg.Filepath)
+	})
 	engo.Mailbox.Listen(messages.ControlMessageType, func(m engo.Message) {
 		log.Printf("%+v", m)
 		msg, ok := m.(messages.ControlMessage)
@@ -123,20 +144,41 @@ func (self *myScene) Setup(u engo.Updater) {
 					Filepath: msg.Data,
 				})
 			} else {
-				engo.Mailbox.Dispatch(messages.ControlMessage{
-					Action: "WorldGenerate",
+				engo.Mailbox.Dispatch(messages.WorldSeedMessage{
+					Seed: worldSeed(),
 				})
+				if *mapPath != "" {
+					engo.Mailbox.Dispatch(messages.ControlMessage{
+						Action: "WorldImport",
+						Data:   *mapPath,
+					})
+				} else {
+					engo.Mailbox.Dispatch(messages.WorldSizeMessage{
+						Size: util.WorldSize{Width: *width, Height: *height},
+					})
+					engo.Mailbox.Dispatch(messages.ControlMessage{
+						Action: "WorldGenerate",
+					})
+				}
 			}
 		}
 	})
 }
 
+// worldSeed returns the seed given on the command line, or a random one
+func worldSeed() int64 {
+	if *seed != 0 {
+		return *seed
+	}
+	return time.Now().UnixNano()
+}
+
 func HandleSaveMessage(world *ecs.World, filepath string) {
 	log.Println("[SaveGame] preparing the save file")
 	// TODO the game should be paused first
 
 	// All systems that save anything should do it here
-	saveFile := &save.SaveFile{}
+	saveFile := &save.SaveFile{Version: save.Version}
 	saveFile.SeenEntityIDs = make(map[uint64]struct{})
 	// Collect data from all systems in the fixed order to avoid writing duplicate Tiles
 	for _, system := range world.Systems() {
@@ -154,6 +196,11 @@ func HandleSaveMessage(world *ecs.World, filepath string) {
 			sys.UpdateSave(saveFile)
 		}
 	}
+	for _, system := range world.Systems() {
+		if sys, ok := system.(*systems.SoilSystem); ok {
+			sys.UpdateSave(saveFile)
+		}
+	}
 
 	log.Printf("[SaveGame] writing the save file '%s'", filepath)
 	f1, err := os.Create(filepath)
@@ -177,6 +224,23 @@ func HandleSaveMessage(world *ecs.World, filepath string) {
 	})
 }
 
+func HandleExportMessage(world *ecs.World, filepath string) {
+	log.Printf("[Export] writing the map '%s'", filepath)
+	text := fmt.Sprintf("Exported to %s", filepath)
+	if err := tiled.Save(filepath, systems.ExportMap(world)); err != nil {
+		log.Printf("[Export] %s", err)
+		text = fmt.Sprintf("Could not export to %s: %s", filepath, err)
+	}
+
+	engo.Mailbox.Dispatch(messages.HUDTextUpdateMessage{
+		Name:      "EventMessage",
+		HideAfter: 3 * time.Second,
+		GetText: func() string {
+			return text
+		},
+	})
+}
+
 func HandleLoadMessage(world *ecs.World, filepath string) {
 	log.Printf("[SaveGame] loading from a save file '%s'", filepath)
 	// TODO the game should be paused first
@@ -189,12 +253,21 @@ func HandleLoadMessage(world *ecs.World, filepath string) {
 	}
 	f2.Close()
 
+	engo.Mailbox.Dispatch(messages.WorldSeedMessage{
+		Seed: saveFile.Seed,
+	})
+
 	// All systems that save anything should do it here
 	for _, system := range world.Systems() {
 		if sys, ok := system.(*systems.WorldTilesSystem); ok {
 			sys.LoadSave(saveFile)
 		}
 	}
+	for _, system := range world.Systems() {
+		if sys, ok := system.(*systems.SoilSystem); ok {
+			sys.LoadSave(saveFile)
+		}
+	}
 	for _, system := range world.Systems() {
 		if sys, ok := system.(*systems.PlantSpawningSystem); ok {
 			sys.LoadSave(saveFile)
@@ -223,6 +296,7 @@ func (*myScene) Exit() {
 }
 
 func main() {
+	flag.Parse()
 	opts := engo.RunOptions{
 		Title:               "Gaea",
 		Width:               worldWidth,
@@ -3,6 +3,8 @@ package messages
 import (
 	"github.com/EngoEngine/ecs"
 	"github.com/EngoEngine/engo"
+	"gogame/calendar"
+	"gogame/util"
 	//"log"
 )
 
@@ -10,11 +12,18 @@ const ControlMessageType string = "ControlMessage"
 const InteractionMessageType string = "InteractionMessage"
 const SaveMessageType string = "SaveMessage"
 const LoadMessageType string = "LoadMessage"
+const ExportMessageType string = "ExportMessage"
 const TileRemoveMessageType string = "TileRemoveMessage"
 const TileReplaceMessageType string = "TileReplaceMessage"
+const TileMoveMessageType string = "TileMoveMessage"
 const CreatureHoveredMessageType string = "CreatureHoveredMessage"
 const PlantHoveredMessageType string = "PlantHoveredMessage"
 const NewPlantMessageType string = "NewPlantMessage"
+const BreedMessageType string = "BreedMessage"
+const SeedMessageType string = "SeedMessage"
+const NutrientsMessageType string = "NutrientsMessage"
+const WorldSeedMessageType string = "WorldSeedMessage"
+const WorldSizeMessageType string = "WorldSizeMessage"
 
 type ControlMessage struct {
 	Action     string
@@ -36,6 +45,11 @@ type LoadMessage struct {
 	Filepath string
 }
 
+// ExportMessage writes the world to a Tiled map (.tmx)
+type ExportMessage struct {
+	Filepath string
+}
+
 type TileRemoveMessage struct {
 	Entity *ecs.BasicEntity
 }
@@ -45,6 +59,11 @@ type TileReplaceMessage struct {
 	ObjectID int
 }
 
+// TileMoveMessage tells that the position or the size of a tile has changed
+type TileMoveMessage struct {
+	Entity *ecs.BasicEntity
+}
+
 type CreatureHoveredMessage struct {
 	EntityID uint64
 }
@@ -58,6 +77,38 @@ type NewPlantMessage struct {
 	Point   *engo.Point
 }
 
+// BreedMessage asks for an offspring of the creature, and of a mate nearby if
+// its species needs one
+type BreedMessage struct {
+	EntityID uint64
+	Time     *calendar.Time
+}
+
+// SeedMessage asks to scatter the seeds of the plant around it
+type SeedMessage struct {
+	EntityID uint64
+	Time     *calendar.Time
+}
+
+// NutrientsMessage returns nutrients to the soil at the point, e.g. from a
+// plant which rotted away. The amount adds to the fertility of the soil.
+type NutrientsMessage struct {
+	Point  engo.Point
+	Amount float32
+}
+
+// WorldSeedMessage (re)seeds the random number generators of all systems,
+// it has to be dispatched before the world is generated or loaded
+type WorldSeedMessage struct {
+	Seed int64
+}
+
+// WorldSizeMessage sets the size of the world. It is dispatched before a world
+// is generated, and by the world itself once imported or loaded.
+type WorldSizeMessage struct {
+	Size util.WorldSize
+}
+
 func (ControlMessage) Type() string {
 	return ControlMessageType
 }
@@ -74,6 +125,10 @@ func (LoadMessage) Type() string {
 	return LoadMessageType
 }
 
+func (ExportMessage) Type() string {
+	return ExportMessageType
+}
+
 func (TileRemoveMessage) Type() string {
 	return TileRemoveMessageType
 }
@@ -82,6 +137,10 @@ func (TileReplaceMessage) Type() string {
 	return TileReplaceMessageType
 }
 
+func (TileMoveMessage) Type() string {
+	return TileMoveMessageType
+}
+
 func (CreatureHoveredMessage) Type() string {
 	return CreatureHoveredMessageType
 }
@@ -93,3 +152,23 @@ func (PlantHoveredMessage) Type() string {
 func (NewPlantMessage) Type() string {
 	return NewPlantMessageType
 }
+
+func (BreedMessage) Type() string {
+	return BreedMessageType
+}
+
+func (SeedMessage) Type() string {
+	return SeedMessageType
+}
+
+func (NutrientsMessage) Type() string {
+	return NutrientsMessageType
+}
+
+func (WorldSeedMessage) Type() string {
+	return WorldSeedMessageType
+}
+
+func (WorldSizeMessage) Type() string {
+	return WorldSizeMessageType
+}
@@ -8,12 +8,18 @@ import (
 const SpacialRequestMessageType string = "SpacialRequestMessage"
 const SpacialResponseMessageType string = "SpacialResponseMessage"
 const DisplayDebugAABBMessageType string = "DisplayDebugAABBMessage"
+const PathRequestMessageType string = "PathRequestMessage"
+const PathResponseMessageType string = "PathResponseMessage"
 
+// SpacialRequestMessage asks for the entities within the AABB, it's answered
+// with a SpacialResponseMessage carrying the same EntityID and EventID
 type SpacialRequestMessage struct {
 	EntityID uint64
 	EventID  uint64
 	Aabb     engo.AABB
 	Filter   func(engo.AABBer) bool
+	From     *engo.Point // If set, the results are sorted by the distance from it
+	Limit    int         // Maximum number of results, 0 for all
 }
 
 type SpacialResponseMessage struct {
@@ -25,6 +31,24 @@ type SpacialResponseMessage struct {
 	Result        []engo.AABBer
 }
 
+// PathRequestMessage asks for a path between the positions of two sprites
+type PathRequestMessage struct {
+	EntityID uint64
+	EventID  uint64
+	From     engo.Point
+	To       engo.Point
+}
+
+// PathResponseMessage carries the waypoints of the path, if one was Found
+type PathResponseMessage struct {
+	EntityID uint64
+	EventID  uint64
+	From     engo.Point
+	To       engo.Point
+	Path     []engo.Point
+	Found    bool
+}
+
 type DisplayDebugAABBMessage struct {
 	Aabbs       []engo.AABB
 	Aabbers     []engo.AABBer
@@ -41,6 +65,14 @@ func (SpacialResponseMessage) Type() string {
 	return SpacialResponseMessageType
 }
 
+func (PathRequestMessage) Type() string {
+	return PathRequestMessageType
+}
+
+func (PathResponseMessage) Type() string {
+	return PathResponseMessageType
+}
+
 func (DisplayDebugAABBMessage) Type() string {
 	return DisplayDebugAABBMessageType
 }
@@ -0,0 +1,140 @@
+// Package pathfinding finds paths over a grid of tiles with A*.
+package pathfinding
+
+import (
+	"container/heap"
+)
+
+// MaxVisited bounds the search, an unreachable goal would otherwise make it
+// visit every reachable tile of the world
+const MaxVisited = 16384
+
+// Step is a tile of the grid, by its column and row
+type Step struct {
+	X, Y int
+}
+
+// Grid tells what it costs to enter the tiles
+type Grid interface {
+	// MovementCost of entering the tile, at least 1, or false if the tile
+	// cannot be entered at all
+	MovementCost(x int, y int) (float64, bool)
+}
+
+// Moves in the order they are tried, diagonal moves are not allowed
+var neighbours = [...]Step{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+type node struct {
+	step  Step
+	cost  float64 // From the start
+	score float64 // Cost and the estimate of the rest
+	order int     // Breaks ties in the order of discovery, for reproducible paths
+	index int
+}
+
+type openSet []*node
+
+func (self openSet) Len() int { return len(self) }
+
+func (self openSet) Less(i, j int) bool {
+	if self[i].score != self[j].score {
+		return self[i].score < self[j].score
+	}
+	return self[i].order < self[j].order
+}
+
+func (self openSet) Swap(i, j int) {
+	self[i], self[j] = self[j], self[i]
+	self[i].index = i
+	self[j].index = j
+}
+
+func (self *openSet) Push(x interface{}) {
+	n := x.(*node)
+	n.index = len(*self)
+	*self = append(*self, n)
+}
+
+func (self *openSet) Pop() interface{} {
+	old := *self
+	n := old[len(old)-1]
+	*self = old[:len(old)-1]
+	return n
+}
+
+func estimate(a Step, b Step) float64 {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	return float64(dx + dy)
+}
+
+// Find returns the cheapest path from one tile to another, without the
+// starting tile, or false if there is none. The starting tile itself may be
+// impassable, so that anything stuck there can still get out.
+func Find(grid Grid, from Step, to Step) ([]Step, bool) {
+	if from == to {
+		return []Step{}, true
+	}
+	if _, ok := grid.MovementCost(to.X, to.Y); !ok {
+		return nil, false
+	}
+
+	open := &openSet{}
+	nodes := map[Step]*node{from: {step: from, score: estimate(from, to)}}
+	cameFrom := make(map[Step]Step)
+	closed := make(map[Step]bool)
+	heap.Push(open, nodes[from])
+	order := 0
+
+	for open.Len() > 0 && len(closed) < MaxVisited {
+		current := heap.Pop(open).(*node)
+		if current.step == to {
+			return walkBack(cameFrom, from, to), true
+		}
+		closed[current.step] = true
+
+		for _, d := range neighbours {
+			next := Step{current.step.X + d.X, current.step.Y + d.Y}
+			if closed[next] {
+				continue
+			}
+			cost, ok := grid.MovementCost(next.X, next.Y)
+			if !ok {
+				continue
+			}
+			cost += current.cost
+			n, seen := nodes[next]
+			if seen && cost >= n.cost {
+				continue
+			}
+			cameFrom[next] = current.step
+			if !seen {
+				order++
+				n = &node{step: next, order: order}
+				nodes[next] = n
+				n.cost, n.score = cost, cost+estimate(next, to)
+				heap.Push(open, n)
+			} else {
+				n.cost, n.score = cost, cost+estimate(next, to)
+				heap.Fix(open, n.index)
+			}
+		}
+	}
+	return nil, false
+}
+
+func walkBack(cameFrom map[Step]Step, from Step, to Step) []Step {
+	var path []Step
+	for step := to; step != from; step = cameFrom[step] {
+		path = append(path, step)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,147 @@
+package pathfinding
+
+import (
+	"reflect"
+	"testing"
+)
+
+// textGrid is a grid drawn with a row per line: '.' costs 1, '~' costs 5,
+// '#' (rock) and 'w' (deep water) are impassable and nothing lies beyond the
+// rows
+type textGrid []string
+
+func (self textGrid) MovementCost(x int, y int) (float64, bool) {
+	if y < 0 || y >= len(self) || x < 0 || x >= len(self[y]) {
+		return 0, false
+	}
+	switch self[y][x] {
+	case '.':
+		return 1, true
+	case '~':
+		return 5, true
+	}
+	return 0, false
+}
+
+// openGrid is passable everywhere but around a walled in tile, and counts the
+// tiles it's asked about
+type openGrid struct {
+	walled Step
+	asked  int
+}
+
+func (self *openGrid) MovementCost(x int, y int) (float64, bool) {
+	self.asked++
+	dx, dy := x-self.walled.X, y-self.walled.Y
+	if dx*dx+dy*dy == 1 {
+		return 0, false
+	}
+	return 1, true
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		grid     textGrid
+		from, to Step
+		found    bool
+		path     []Step
+	}{
+		{
+			name:  "straight",
+			grid:  textGrid{"...."},
+			from:  Step{0, 0},
+			to:    Step{3, 0},
+			found: true,
+			path:  []Step{{1, 0}, {2, 0}, {3, 0}},
+		},
+		{
+			name: "around rock and deep water",
+			grid: textGrid{
+				"...",
+				"#w.",
+				"...",
+			},
+			from:  Step{0, 0},
+			to:    Step{0, 2},
+			found: true,
+			path:  []Step{{1, 0}, {2, 0}, {2, 1}, {2, 2}, {1, 2}, {0, 2}},
+		},
+		{
+			name: "around costly terrain",
+			grid: textGrid{
+				".~.",
+				"...",
+			},
+			from:  Step{0, 0},
+			to:    Step{2, 0},
+			found: true,
+			path:  []Step{{0, 1}, {1, 1}, {2, 1}, {2, 0}},
+		},
+		{
+			name: "through costly terrain when it's cheaper",
+			grid: textGrid{
+				".~.",
+				"###",
+			},
+			from:  Step{0, 0},
+			to:    Step{2, 0},
+			found: true,
+			path:  []Step{{1, 0}, {2, 0}},
+		},
+		{
+			name: "unreachable",
+			grid: textGrid{
+				"..#.",
+				"..#.",
+			},
+			from:  Step{0, 0},
+			to:    Step{3, 1},
+			found: false,
+		},
+		{
+			name:  "impassable goal",
+			grid:  textGrid{"..w"},
+			from:  Step{0, 0},
+			to:    Step{2, 0},
+			found: false,
+		},
+		{
+			name:  "out of an impassable start",
+			grid:  textGrid{"#.."},
+			from:  Step{0, 0},
+			to:    Step{2, 0},
+			found: true,
+			path:  []Step{{1, 0}, {2, 0}},
+		},
+		{
+			name:  "already there",
+			grid:  textGrid{"..."},
+			from:  Step{1, 0},
+			to:    Step{1, 0},
+			found: true,
+			path:  []Step{},
+		},
+	}
+	for _, test := range tests {
+		path, found := Find(test.grid, test.from, test.to)
+		if found != test.found {
+			t.Errorf("%s: found is %v, want %v", test.name, found, test.found)
+			continue
+		}
+		if found && !reflect.DeepEqual(path, test.path) {
+			t.Errorf("%s: path is %v, want %v", test.name, path, test.path)
+		}
+	}
+}
+
+func TestFindGivesUpAfterMaxVisited(t *testing.T) {
+	grid := &openGrid{walled: Step{0, 0}}
+	if _, found := Find(grid, Step{50, 50}, Step{0, 0}); found {
+		t.Fatal("found a path to a walled in tile")
+	}
+	// Each visited tile asks about its neighbours, and the goal is asked about first
+	if grid.asked > 4*MaxVisited+1 {
+		t.Errorf("asked about %d tiles, more than %d tiles visited would", grid.asked, MaxVisited)
+	}
+}
@@ -3,12 +3,22 @@ package save
 import (
 	"gogame/data"
 	"gogame/life/plants"
+	"gogame/util"
 )
 
+// Version of the save files written, those without one are 0
+//
+//	1: the soil is saved, and plants hold the nutrients they took up from it
+const Version = 1
+
 type SaveFile struct {
+	Version   int              `json:"version"`
+	Seed      int64            `json:"seed"`
+	Size      util.WorldSize   `json:"size"`
 	Tiles     []*data.Tile     `json:"tiles"`
 	Creatures []*data.Creature `json:"creatures"`
 	Plants    []*plants.Plant  `json:"plants"`
+	Soil      []*data.Soil     `json:"soil"`
 
 	SeenEntityIDs map[uint64]struct{} `json:"-"`
 }
@@ -0,0 +1,139 @@
+package systems
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo/common"
+	"gogame/config"
+	"gogame/data"
+	"gogame/shaders"
+	"gogame/terrain"
+	"gogame/util"
+)
+
+// Layer of the terrain transitions, between the ground and the plants
+const transitionLayer float32 = 1
+
+type gridIndex struct {
+	X, Y int
+}
+
+// Transition is a partially transparent terrain drawn over a ground tile,
+// where the tileset has no tile mixing both terrains. It is only ever drawn,
+// so it isn't saved and doesn't take part in any other system.
+type Transition struct {
+	ecs.BasicEntity
+	*common.RenderComponent
+	*common.SpaceComponent
+}
+
+func tileGridIndex(tile *data.Tile) gridIndex {
+	x, y := util.ToGridIndex(tile.SpaceComponent.Position.X, tile.SpaceComponent.Position.Y)
+	return gridIndex{x, y}
+}
+
+func (self *WorldTilesSystem) removeGround(tile *data.Tile) {
+	if tile.Layer != 0 {
+		return
+	}
+	index := tileGridIndex(tile)
+	if self.ground[index] == tile {
+		delete(self.ground, index)
+		self.AutotileAround(tile)
+	}
+}
+
+// GroundAt returns the ground tile at the given column and row, if any
+func (self *WorldTilesSystem) GroundAt(x, y int) *data.Tile {
+	return self.ground[gridIndex{x, y}]
+}
+
+func (self *WorldTilesSystem) terrainAt(x, y int) string {
+	tile := self.GroundAt(x, y)
+	if tile == nil || tile.Object == nil {
+		return ""
+	}
+	return tile.Object.Terrain
+}
+
+// vertexTerrain is the terrain at the top-left corner of the tile at x, y
+func (self *WorldTilesSystem) vertexTerrain(x, y int) string {
+	return self.autotiler.VertexTerrain(
+		self.terrainAt(x-1, y-1), self.terrainAt(x, y-1),
+		self.terrainAt(x-1, y), self.terrainAt(x, y),
+	)
+}
+
+// Autotile picks transition sprites for all ground tiles of the world
+func (self *WorldTilesSystem) Autotile() {
+	for _, tile := range self.tiles {
+		if tile.Layer == 0 {
+			self.autotile(tileGridIndex(tile))
+		}
+	}
+}
+
+// AutotileAround picks transition sprites for the tile and its neighbours,
+// e.g. after its ground has changed
+func (self *WorldTilesSystem) AutotileAround(tile *data.Tile) {
+	index := tileGridIndex(tile)
+	for x := index.X - 1; x <= index.X+1; x++ {
+		for y := index.Y - 1; y <= index.Y+1; y++ {
+			self.autotile(gridIndex{x, y})
+		}
+	}
+}
+
+func (self *WorldTilesSystem) autotile(index gridIndex) {
+	if self.autotiler == nil {
+		return
+	}
+	tile := self.ground[index]
+	if tile == nil || !self.autotiler.Participates(tile.Object.Terrain) {
+		self.setTransition(index, terrain.NoOverlay, nil)
+		return
+	}
+	x, y := index.X, index.Y
+	corners := terrain.Corners{
+		self.vertexTerrain(x, y), self.vertexTerrain(x+1, y),
+		self.vertexTerrain(x, y+1), self.vertexTerrain(x+1, y+1),
+	}
+	base, overlay := self.autotiler.Sprites(tile.Object.Terrain, corners)
+	tile.RenderComponent.Drawable = tile.Object.Spritesheet.Cell(base)
+	self.setTransition(index, overlay, tile)
+}
+
+// setTransition draws the overlay sprite over the ground tile, or removes the
+// existing one
+func (self *WorldTilesSystem) setTransition(index gridIndex, overlay int, tile *data.Tile) {
+	transition, ok := self.transitions[index]
+	if overlay == terrain.NoOverlay {
+		if ok {
+			delete(self.transitions, index)
+			self.world.RemoveEntity(transition.BasicEntity)
+		}
+		return
+	}
+	if ok {
+		transition.RenderComponent.Drawable = tile.Object.Spritesheet.Cell(overlay)
+		return
+	}
+	transition = &Transition{BasicEntity: ecs.NewBasic()}
+	transition.SpaceComponent = &common.SpaceComponent{
+		Position: tile.SpaceComponent.Position,
+		Width:    float32(config.SpriteWidth),
+		Height:   float32(config.SpriteHeight),
+	}
+	transition.RenderComponent = &common.RenderComponent{
+		Drawable: tile.Object.Spritesheet.Cell(overlay),
+	}
+	transition.RenderComponent.SetZIndex(transitionLayer)
+	transition.RenderComponent.SetShader(shaders.DefaultShader)
+	self.transitions[index] = transition
+
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *common.RenderSystem:
+			sys.Add(&transition.BasicEntity, transition.RenderComponent, transition.SpaceComponent)
+		}
+	}
+}
@@ -7,11 +7,15 @@ import (
 	"github.com/EngoEngine/engo/common"
 	"github.com/ulule/deepcopier"
 	"gogame/assets"
+	"gogame/calendar"
+	"gogame/config"
 	"gogame/data"
 	"gogame/messages"
 	"gogame/save"
+	"gogame/tiled"
 	"gogame/util"
 	"log"
+	"math/rand"
 	"time"
 )
 
@@ -24,6 +28,9 @@ type CreatureSpawningSystem struct {
 	world        *ecs.World
 	mouseTracker CreatureMouseTracker
 	entities     []*data.Creature
+
+	// Drives all decisions of the creatures
+	rand *rand.Rand
 }
 
 func NewCreature(creatureID int, position *engo.Point) *data.Creature {
@@ -36,7 +43,22 @@ func NewCreature(creatureID int, position *engo.Point) *data.Creature {
 }
 
 func (self *CreatureSpawningSystem) Add(entity *data.Creature) {
-	entity.IsAlive = true
+	if entity.Behavior == "" {
+		// Saved before species had behaviors
+		entity.Behavior = assets.GetCreatureById(entity.ID).Behavior
+	}
+	entity.Tree = assets.GetBehaviorTree(entity.Behavior)
+	if entity.IsAlive && entity.MaxHealth == 0 {
+		// Saved before creatures had health
+		species := assets.GetCreatureById(entity.ID)
+		entity.MaxHealth, entity.Health = species.MaxHealth, species.MaxHealth
+		entity.StarvationDamage, entity.HealingRate = species.StarvationDamage, species.HealingRate
+		entity.CorpseID, entity.DecayRate = species.CorpseID, species.DecayRate
+	}
+	if entity.IsAlive && entity.BasalMetabolism == 0 {
+		// Saved before creatures had a metabolism
+		entity.Metabolism = assets.GetCreatureById(entity.ID).Metabolism
+	}
 
 	self.entities = append(self.entities, entity)
 
@@ -54,6 +76,7 @@ func (self *CreatureSpawningSystem) New(w *ecs.World) {
 	log.Println("CreatureSpawningSystem was added to the Scene")
 
 	self.world = w
+	self.rand = util.NewRand(0, "creatures")
 	self.mouseTracker.BasicEntity = ecs.NewBasic()
 	self.mouseTracker.MouseComponent = common.MouseComponent{Track: true}
 
@@ -69,11 +92,25 @@ func (self *CreatureSpawningSystem) New(w *ecs.World) {
 	engo.Mailbox.Listen(messages.SpacialResponseMessageType, self.HandleSpacialResponseMessage)
 	engo.Mailbox.Listen(messages.CreatureHoveredMessageType, self.HandleCreatureHoveredMessage)
 	engo.Mailbox.Listen(messages.TimeSecondPassedMessageType, self.HandleTimeSecondPassedMessage)
+	engo.Mailbox.Listen(messages.TimeSunriseMessageType, self.HandleDaylightMessage)
+	engo.Mailbox.Listen(messages.TimeSunsetMessageType, self.HandleDaylightMessage)
+	engo.Mailbox.Listen(messages.WorldSeedMessageType, self.HandleWorldSeedMessage)
+	engo.Mailbox.Listen(messages.BreedMessageType, self.HandleBreedMessage)
 }
 
 // Update is ran every frame, with `dt` being the time
 // in seconds since the last frame
 func (self *CreatureSpawningSystem) Update(dt float32) {
+	// Creatures move in game time
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *TimeSystem:
+			dt *= sys.Speed()
+		}
+	}
+	if dt <= 0 {
+		return
+	}
 	for _, entity := range self.entities {
 		entity.Update(dt)
 	}
@@ -88,7 +125,9 @@ func (self *CreatureSpawningSystem) Remove(e ecs.BasicEntity) {
 		}
 	}
 	if delete >= 0 {
-		self.entities = append(self.entities[:delete], self.entities[delete+1:]...)
+		// A new slice, as creatures may be removed while iterating over them
+		entities := append([]*data.Creature{}, self.entities[:delete]...)
+		self.entities = append(entities, self.entities[delete+1:]...)
 	}
 }
 
@@ -101,6 +140,11 @@ func (self *CreatureSpawningSystem) Get(entityID uint64) *data.Creature {
 	return nil
 }
 
+// Creatures returns all creatures currently known to the system
+func (self *CreatureSpawningSystem) Creatures() []*data.Creature {
+	return self.entities
+}
+
 func (self *CreatureSpawningSystem) HandleSpacialResponseMessage(m engo.Message) {
 	log.Printf("SpacialResponseMessage %+v", m)
 	msg, ok := m.(messages.SpacialResponseMessage)
@@ -111,6 +155,13 @@ func (self *CreatureSpawningSystem) HandleSpacialResponseMessage(m engo.Message)
 	if entity == nil {
 		log.Println(
 			fmt.Sprintf("[SpacialResponseMessage] Got a message for an unknown entity %+v", msg))
+		return
+	}
+	if msg.EventID != entity.LastEventID {
+		// The creature has asked for something else since
+		log.Println(
+			fmt.Sprintf("[SpacialResponseMessage] Discarding a stale response %d, expected %d", msg.EventID, entity.LastEventID))
+		return
 	}
 	if len(msg.Result) > 0 {
 		for _, v := range msg.Result {
@@ -119,7 +170,13 @@ func (self *CreatureSpawningSystem) HandleSpacialResponseMessage(m engo.Message)
 					"Found tile", tile, tile.SpaceComponent.Position,
 					"our position", entity.Tile.SpaceComponent.Position)
 				if entity.MovementTarget == nil {
+					path, found := self.findPath(entity.Tile, tile)
+					if !found {
+						log.Println("No way to", tile, "from", entity.Tile.SpaceComponent.Position)
+						continue
+					}
 					entity.MovementTarget = tile
+					entity.Path = path
 
 					engo.Mailbox.Dispatch(messages.DisplayDebugAABBMessage{
 						Aabbers: []engo.AABBer{tile},
@@ -145,6 +202,89 @@ func (self *CreatureSpawningSystem) HandleSpacialResponseMessage(m engo.Message)
 			}
 		}
 	}
+	if entity.MovementTarget == nil && entity.Activity == data.Wandering {
+		// Nowhere to go
+		entity.BecomeIdle()
+	}
+}
+
+// HandleBreedMessage gives birth to an offspring of the creature, if it finds a
+// mate nearby or doesn't need one
+func (self *CreatureSpawningSystem) HandleBreedMessage(m engo.Message) {
+	msg, ok := m.(messages.BreedMessage)
+	if !ok {
+		return
+	}
+	parent := self.Get(msg.EntityID)
+	if parent == nil || !parent.CanBreed(msg.Time) {
+		return
+	}
+	parents := []*data.Creature{parent}
+	if parent.MateRadius > 0 {
+		mate := self.findMate(parent, msg.Time)
+		if mate == nil {
+			return
+		}
+		parents = append(parents, mate)
+	}
+	self.Breed(msg.Time, parents...)
+}
+
+// findMate picks the nearest creature of the same species which can breed too
+func (self *CreatureSpawningSystem) findMate(c *data.Creature, t *calendar.Time) *data.Creature {
+	var mate *data.Creature
+	var best float32
+	position := c.Tile.SpaceComponent.Position
+	for _, e := range self.entities {
+		if e == c || e.ID != c.ID || !e.IsAlive || !e.CanBreed(t) {
+			continue
+		}
+		d := position.PointDistance(e.Tile.SpaceComponent.Position)
+		if d <= c.MateRadius*float32(config.SpriteWidth) && (mate == nil || d < best) {
+			mate, best = e, d
+		}
+	}
+	return mate
+}
+
+// Breed adds an offspring of the parents next to the first one. It inherits
+// their genome and some of their food.
+func (self *CreatureSpawningSystem) Breed(t *calendar.Time, parents ...*data.Creature) *data.Creature {
+	first := parents[0]
+	position := first.Tile.SpaceComponent.Position
+	child := NewCreature(first.ID, &position)
+
+	var genomes []*data.Genome
+	for _, p := range parents {
+		genomes = append(genomes, &p.Genome)
+		if p.Generation >= child.Generation {
+			child.Generation = p.Generation + 1
+		}
+		p.Food -= first.BreedingCost / float32(len(parents))
+		p.LastBred = t.SecondsSinceBeginningOfTime
+	}
+	child.Genome = data.Inherit(self.rand, first.MutationRate, genomes...)
+	child.Food = first.BreedingCost
+	if child.Food > child.MaxFood {
+		child.Food = child.MaxFood
+	}
+	// Grows up before breeding itself
+	child.LastBred = t.SecondsSinceBeginningOfTime
+
+	log.Println(first, "had an offspring of generation", child.Generation, child.Genome)
+	self.Add(child)
+	return child
+}
+
+// findPath asks the SpacialSystem for the way from one tile to another
+func (self *CreatureSpawningSystem) findPath(from *data.Tile, to *data.Tile) ([]engo.Point, bool) {
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *SpacialSystem:
+			return sys.FindPath(from.SpaceComponent.Position, to.SpaceComponent.Position)
+		}
+	}
+	return nil, false
 }
 
 func (self *CreatureSpawningSystem) HandleCollisionMessage(message engo.Message) {
@@ -189,10 +329,114 @@ func (self *CreatureSpawningSystem) HandleTimeSecondPassedMessage(m engo.Message
 		return
 	}
 	for _, e := range self.entities {
-		e.UpdateActivity(msg.Time)
+		self.perceive(e)
+		e.UpdateActivity(msg.Time, self.rand)
 	}
 }
 
+// elder tells whether a is older than b, or was born first if they're as old
+func elder(a, b *data.Creature) bool {
+	if a.Age != b.Age {
+		return a.Age > b.Age
+	}
+	return a.BasicEntity.ID() < b.BasicEntity.ID()
+}
+
+// perceive lets the creature notice the nearest predator and prey it sees, the
+// food and the water, and its flock, unless it's asleep
+func (self *CreatureSpawningSystem) perceive(c *data.Creature) {
+	c.Threat, c.Quarry = nil, nil
+	c.Flockmates, c.Leader = nil, nil
+	if !c.IsAlive || c.Activity == data.Sleeping {
+		return
+	}
+	position := c.Tile.SpaceComponent.Position
+	flockRadius := c.FlockRadius * float32(config.SpriteWidth)
+	var threat, quarry float32
+	for _, e := range self.entities {
+		if e == c || !e.IsAlive {
+			continue
+		}
+		d := position.PointDistance(e.Tile.SpaceComponent.Position)
+		if e.ID == c.ID && d <= flockRadius {
+			c.Flockmates = append(c.Flockmates, e)
+			if elder(e, c) && (c.Leader == nil || elder(e, c.Leader)) {
+				c.Leader = e
+			}
+		}
+		if !c.Sees(e.Tile.SpaceComponent.Position) {
+			continue
+		}
+		if e.Hunts(c) && (c.Threat == nil || d < threat) {
+			c.Threat, threat = e, d
+		}
+		if c.Hunts(e) && (c.Quarry == nil || d < quarry) {
+			c.Quarry, quarry = e, d
+		}
+	}
+	if c.Threat != nil {
+		c.Remember(data.ThreatMemory, c.Threat.Tile)
+	}
+	self.look(c)
+}
+
+// look lets the creature notice the food and the water in sight, and forget
+// the food it sees is gone
+func (self *CreatureSpawningSystem) look(c *data.Creature) {
+	var spacial *SpacialSystem
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *SpacialSystem:
+			spacial = sys
+		}
+	}
+	if spacial == nil {
+		return
+	}
+	seen := make(map[engo.Point]bool)
+	var water *data.Tile
+	position := c.Tile.SpaceComponent.Position
+	for _, e := range spacial.InRadius(position, c.Perception*float32(config.SpriteWidth), nil) {
+		tile, ok := e.(*data.Tile)
+		if !ok || !c.Sees(tile.SpaceComponent.Position) {
+			continue
+		}
+		if c.FindFood(tile) {
+			seen[tile.SpaceComponent.Position] = true
+			c.Remember(data.FoodMemory, tile)
+		} else if water == nil && tile.IsWater() {
+			// Only the nearest water is worth remembering
+			water = tile
+			c.Remember(data.WaterMemory, tile)
+		}
+	}
+	c.ForgetGoneFood(seen)
+}
+
+// HandleDaylightMessage lets the creatures know that the sun has risen or set
+func (self *CreatureSpawningSystem) HandleDaylightMessage(m engo.Message) {
+	var t *calendar.Time
+	switch msg := m.(type) {
+	case messages.TimeSunriseMessage:
+		t = msg.Time
+	case messages.TimeSunsetMessage:
+		t = msg.Time
+	default:
+		return
+	}
+	for _, e := range self.entities {
+		e.ReactToDaylight(t)
+	}
+}
+
+func (self *CreatureSpawningSystem) HandleWorldSeedMessage(m engo.Message) {
+	msg, ok := m.(messages.WorldSeedMessage)
+	if !ok {
+		return
+	}
+	self.rand = util.NewRand(msg.Seed, "creatures")
+}
+
 func (self *CreatureSpawningSystem) UpdateSave(saveFile *save.SaveFile) {
 	for _, e := range self.entities {
 		entityID := e.BasicEntity.ID()
@@ -203,6 +447,39 @@ func (self *CreatureSpawningSystem) UpdateSave(saveFile *save.SaveFile) {
 	}
 }
 
+// UpdateMap adds the creatures to an exported map, with their current state
+func (self *CreatureSpawningSystem) UpdateMap(m *tiled.Map) {
+	for _, e := range self.entities {
+		entityID := e.BasicEntity.ID()
+		if _, ok := m.SeenEntityIDs[entityID]; ok {
+			continue
+		}
+		x, y := util.ToGridIndex(e.SpaceComponent.Position.X, e.SpaceComponent.Position.Y)
+		m.Entities = append(m.Entities, tiled.Entity{
+			Kind:     tiled.CreatureKind,
+			ID:       e.ID,
+			ObjectID: e.Tile.ObjectID,
+			X:        x,
+			Y:        y,
+			Properties: map[string]interface{}{
+				"name":           e.Name,
+				"food":           e.Food,
+				"sleep":          e.Sleep,
+				"generation":     e.Generation,
+				"health":         e.Health,
+				"lifespan":       e.Lifespan,
+				"eating_speed":   e.EatingSpeed,
+				"max_food":       e.MaxFood,
+				"max_sleep":      e.MaxSleep,
+				"min_food":       e.MinFood,
+				"min_sleep":      e.MinSleep,
+				"movement_speed": e.MovementSpeed,
+			},
+		})
+		m.SeenEntityIDs[entityID] = struct{}{}
+	}
+}
+
 func (self *CreatureSpawningSystem) LoadSave(saveFile *save.SaveFile) {
 	log.Printf("[CreatureSpawningSystem] Creatures in the save file: %d\n", len(saveFile.Creatures))
 	for _, c := range saveFile.Creatures {
@@ -0,0 +1,80 @@
+package systems_test
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/calendar"
+	"gogame/data"
+	"gogame/systems/stubworld"
+	"gogame/util"
+	"testing"
+)
+
+// newGrazer puts a grazer with the food and sleep in a stub world, with plants
+// at the offsets in tiles, listening on a fresh mailbox
+func newGrazer(food, sleep float32, plants ...[2]int) *stubworld.World {
+	engo.Mailbox = &engo.MessageManager{}
+	world := stubworld.New(1, 6, 1)
+	world.Creature.Food, world.Creature.Sleep = food, sleep
+	world.AddPlants(plants, 50)
+	world.Listen()
+	return world
+}
+
+// tick runs the creature for the seconds of game time from noon, calling back
+// after each one
+func tick(world *stubworld.World, seconds int, each func()) {
+	t := &calendar.Time{Hour: 12}
+	r := util.NewRand(1, "creatures")
+	for i := 0; i < seconds; i++ {
+		world.Tick(t, r)
+		each()
+	}
+}
+
+func TestGrazerEatsNearestPlant(t *testing.T) {
+	world := newGrazer(10, 16, [2]int{2, 0}, [2]int{-4, 3})
+	c := world.Creature
+	near, far := world.PlantAt(2, 0), world.PlantAt(-4, 3)
+	ateAtNear := false
+	tick(world, 60, func() {
+		if c.Activity == data.Eating && c.SpaceComponent.Position == near.SpaceComponent.Position {
+			ateAtNear = true
+		}
+	})
+	if !ateAtNear {
+		t.Error("the hungry creature didn't eat at the nearest plant")
+	}
+	if c.Food <= 10 {
+		t.Errorf("the creature has %.1f food, no more than it started with", c.Food)
+	}
+	if world.PlantAt(-4, 3) != far || far.AccessibleResource.Amount != 50 {
+		t.Error("the creature ate the farther plant")
+	}
+}
+
+func TestGrazerSleepsWhenTired(t *testing.T) {
+	world := newGrazer(300, 0, [2]int{2, 0})
+	c := world.Creature
+	tick(world, 10, func() {})
+	if c.Activity != data.Sleeping {
+		t.Errorf("the tired creature is %s instead of sleeping", c.Activity)
+	}
+}
+
+func TestGrazerWandersWhenFed(t *testing.T) {
+	world := newGrazer(300, 16, [2]int{2, 0})
+	c := world.Creature
+	start := c.SpaceComponent.Position
+	wandered := false
+	tick(world, 60, func() {
+		if c.Activity == data.Eating || c.Activity == data.Sleeping {
+			t.Fatalf("the fed and rested creature is %s", c.Activity)
+		}
+		if c.Activity == data.Wandering {
+			wandered = true
+		}
+	})
+	if !wandered || c.SpaceComponent.Position == start {
+		t.Error("the fed and rested creature didn't wander off")
+	}
+}
@@ -0,0 +1,33 @@
+package systems
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/assets"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+)
+
+type testScene struct{}
+
+func (*testScene) Type() string { return "systems-test" }
+
+func (*testScene) Preload() {
+	assets.InitAssets()
+}
+
+func (*testScene) Setup(engo.Updater) {}
+
+func TestMain(m *testing.M) {
+	// Assets are looked up from the root of the repository
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	log.SetOutput(ioutil.Discard)
+	engo.Run(engo.RunOptions{
+		HeadlessMode: true,
+		NoRun:        true,
+	}, &testScene{})
+	os.Exit(m.Run())
+}
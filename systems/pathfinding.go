@@ -0,0 +1,89 @@
+package systems
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/config"
+	"gogame/data"
+	"gogame/messages"
+	"gogame/pathfinding"
+	"gogame/util"
+	"log"
+)
+
+// block keeps count of the impassable objects on top of the ground
+func (self *WorldTilesSystem) block(tile *data.Tile, count int) {
+	if tile.Layer == 0 || tile.Object == nil || !tile.Object.Impassable {
+		return
+	}
+	index := tileGridIndex(tile)
+	self.blocked[index] += count
+	if self.blocked[index] <= 0 {
+		delete(self.blocked, index)
+	}
+}
+
+// MovementCost of walking onto the tile at the given column and row, false if
+// there is no ground or it is blocked
+func (self *WorldTilesSystem) MovementCost(x int, y int) (float64, bool) {
+	ground := self.GroundAt(x, y)
+	if ground == nil || ground.Object == nil || ground.Object.Impassable {
+		return 0, false
+	}
+	if self.blocked[gridIndex{x, y}] > 0 {
+		return 0, false
+	}
+	if ground.Object.MovementCost < 1 {
+		return 1, true
+	}
+	return float64(ground.Object.MovementCost), true
+}
+
+// pointGridIndex is the tile under the centre of a sprite at the given position
+func pointGridIndex(p engo.Point) pathfinding.Step {
+	x, y := util.ToGridIndex(p.X+float32(config.SpriteWidth)/2, p.Y+float32(config.SpriteHeight)/2)
+	return pathfinding.Step{X: x, Y: y}
+}
+
+// FindPath returns the positions of the tiles to walk through, from a sprite at
+// one position to another, ending with the destination itself
+func (self *SpacialSystem) FindPath(from engo.Point, to engo.Point) ([]engo.Point, bool) {
+	var grid pathfinding.Grid
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *WorldTilesSystem:
+			grid = sys
+		}
+	}
+	if grid == nil {
+		return nil, false
+	}
+	steps, ok := pathfinding.Find(grid, pointGridIndex(from), pointGridIndex(to))
+	if !ok {
+		return nil, false
+	}
+	path := make([]engo.Point, 0, len(steps))
+	for _, s := range steps {
+		path = append(path, *util.ToPoint(s.X, s.Y))
+	}
+	if len(path) > 0 {
+		path[len(path)-1] = to
+	}
+	return path, true
+}
+
+func (self *SpacialSystem) HandlePathRequestMessage(m engo.Message) {
+	log.Printf("[SpacialSystem] %+v", m)
+	msg, ok := m.(messages.PathRequestMessage)
+	if !ok {
+		return
+	}
+	path, found := self.FindPath(msg.From, msg.To)
+	engo.Mailbox.Dispatch(messages.PathResponseMessage{
+		EntityID: msg.EntityID,
+		EventID:  msg.EventID,
+		From:     msg.From,
+		To:       msg.To,
+		Path:     path,
+		Found:    found,
+	})
+}
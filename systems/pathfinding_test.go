@@ -0,0 +1,64 @@
+package systems
+
+import (
+	"github.com/EngoEngine/engo"
+	"gogame/assets"
+	"gogame/messages"
+	"gogame/terrain"
+	"gogame/util"
+	"testing"
+)
+
+// requestPath asks for a path through the mailbox and returns the reply
+func requestPath(t *testing.T, from, to engo.Point) messages.PathResponseMessage {
+	t.Helper()
+	var replies []messages.PathResponseMessage
+	engo.Mailbox.Listen(messages.PathResponseMessageType, func(m engo.Message) {
+		if msg, ok := m.(messages.PathResponseMessage); ok {
+			replies = append(replies, msg)
+		}
+	})
+	engo.Mailbox.Dispatch(messages.PathRequestMessage{EntityID: 7, EventID: 42, From: from, To: to})
+	if len(replies) != 1 {
+		t.Fatalf("got %d replies to the path request, want 1", len(replies))
+	}
+	reply := replies[0]
+	if reply.EntityID != 7 || reply.EventID != 42 || reply.From != from || reply.To != to {
+		t.Errorf("the reply %+v doesn't answer the request", reply)
+	}
+	return reply
+}
+
+func TestPathRequestMessage(t *testing.T) {
+	_, worldTiles := newTestTilesWorld()
+	// Grass with a column of deep water in the middle, but for its last row
+	grass, water := assets.GetObjectByTerrain(terrain.Grass), assets.GetObjectByTerrain(terrain.WaterDeep)
+	for x := 0; x < 5; x++ {
+		for y := 0; y < 4; y++ {
+			object := grass
+			if x == 2 && y < 3 {
+				object = water
+			}
+			worldTiles.Add(NewTile(object.ID, util.ToPoint(x, y), 0, nil))
+		}
+	}
+
+	from, to := *util.ToPoint(0, 0), *util.ToPoint(4, 0)
+	reply := requestPath(t, from, to)
+	if !reply.Found {
+		t.Fatal("no path around the water")
+	}
+	// Down to the last row, across and up again
+	if len(reply.Path) != 10 || reply.Path[len(reply.Path)-1] != to {
+		t.Errorf("the path %v isn't the way around the water", reply.Path)
+	}
+	for _, p := range reply.Path {
+		if x, y := util.ToGridIndex(p.X, p.Y); x == 2 && y < 3 {
+			t.Errorf("the path goes through the water at %v", p)
+		}
+	}
+
+	if reply := requestPath(t, from, *util.ToPoint(2, 0)); reply.Found {
+		t.Errorf("found a path into deep water: %v", reply.Path)
+	}
+}
@@ -5,29 +5,62 @@ import (
 	"github.com/EngoEngine/engo"
 	"github.com/EngoEngine/engo/common"
 	"github.com/ulule/deepcopier"
+	"gogame/calendar"
+	"gogame/config"
+	"gogame/data"
 	"gogame/life/plants"
 	"gogame/messages"
 	"gogame/save"
 	"gogame/shaders"
+	"gogame/tiled"
+	"gogame/util"
 	"log"
+	"math/rand"
 )
 
+// Layer of the world the plants grow on
+const plantLayer float32 = 2
+
 type PlantSpawningSystem struct {
 	world    *ecs.World
 	shader   common.Shader
 	entities []*plants.Plant
+	rand     *rand.Rand
 }
 
 func NewPlant(plantID int, position *engo.Point) *plants.Plant {
 	plant := plants.GetPlantByID(plantID)
-	tile := NewTile(plant.ObjectID, position, 2, &common.CollisionComponent{Main: 0, Group: 0})
+	tile := NewTile(plant.ObjectID, position, plantLayer, &common.CollisionComponent{Main: 0, Group: 0})
 	entity := &plants.Plant{ID: plantID, Tile: tile}
 	// Initialise plant's stats from its initial record
 	deepcopier.Copy(plant).To(entity)
+	// Made of the nutrients of the growth it starts with
+	entity.Nutrients = entity.Growth * entity.NutrientUptake
 	return entity
 }
 
 func (self *PlantSpawningSystem) Add(entity *plants.Plant) {
+	species := plants.GetPlantByID(entity.ID)
+	if entity.SeedlingID == 0 {
+		// Saved before plants scattered seeds
+		entity.Seeding = species.Seeding
+	}
+	if entity.DeadID == 0 && entity.DecayRate == 0 {
+		// Saved before plants died
+		entity.DeadID, entity.Senescence, entity.DecayRate = species.DeadID, species.Senescence, species.DecayRate
+	}
+	if entity.SeasonalGrowth == nil {
+		// Saved before plants grew by the season
+		entity.SeasonalGrowth = species.SeasonalGrowth
+	}
+	if entity.IsAlive && entity.CompetitionRadius == 0 {
+		// Saved before plants competed
+		entity.Competition = species.Competition
+	}
+	if entity.NutrientUptake == 0 && entity.WaterUptake == 0 {
+		// Saved before plants took up the soil
+		entity.NutrientUptake, entity.WaterUptake = species.NutrientUptake, species.WaterUptake
+	}
 	self.entities = append(self.entities, entity)
 
 	// Add the entity to the various systems
@@ -36,6 +69,9 @@ func (self *PlantSpawningSystem) Add(entity *plants.Plant) {
 		case *WorldTilesSystem:
 			sys.Add(entity.Tile)
 			entity.Tile.RenderComponent.SetShader(self.shader)
+		case *SoilSystem:
+			position := entity.Tile.SpaceComponent.Position
+			entity.Soil = sys.At(util.ToGridIndex(position.X, position.Y))
 		}
 	}
 }
@@ -46,11 +82,14 @@ func (self *PlantSpawningSystem) New(w *ecs.World) {
 
 	self.world = w
 	self.shader = shaders.WindShader
+	self.rand = util.NewRand(0, "plants")
 
 	engo.Mailbox.Listen(messages.NewPlantMessageType, self.HandleNewPlantMessage)
 	engo.Mailbox.Listen(messages.PlantHoveredMessageType, self.HandlePlantHoveredMessage)
 	engo.Mailbox.Listen(messages.TimeSecondPassedMessageType, self.HandleTimeSecondPassedMessage)
 	engo.Mailbox.Listen(messages.ControlMessageType, self.HandleControlMessage)
+	engo.Mailbox.Listen(messages.SeedMessageType, self.HandleSeedMessage)
+	engo.Mailbox.Listen(messages.WorldSeedMessageType, self.HandleWorldSeedMessage)
 }
 
 func (self *PlantSpawningSystem) changeShader(wave float32, speed float32) {
@@ -87,7 +126,9 @@ func (self *PlantSpawningSystem) Remove(e ecs.BasicEntity) {
 		}
 	}
 	if delete >= 0 {
-		self.entities = append(self.entities[:delete], self.entities[delete+1:]...)
+		// A new slice, as plants may be removed while iterating over them
+		entities := append([]*plants.Plant{}, self.entities[:delete]...)
+		self.entities = append(entities, self.entities[delete+1:]...)
 	}
 }
 
@@ -100,6 +141,11 @@ func (self *PlantSpawningSystem) Get(entityID uint64) *plants.Plant {
 	return nil
 }
 
+// Plants returns all plants currently known to the system
+func (self *PlantSpawningSystem) Plants() []*plants.Plant {
+	return self.entities
+}
+
 func (self *PlantSpawningSystem) HandleNewPlantMessage(m engo.Message) {
 	log.Printf("%+v", m)
 	msg, ok := m.(messages.NewPlantMessage)
@@ -130,11 +176,144 @@ func (self *PlantSpawningSystem) HandleTimeSecondPassedMessage(m engo.Message) {
 	if !ok {
 		return
 	}
+	if msg.Time.SecondsSinceBeginningOfTime%calendar.SecondsPerHour == 0 {
+		self.Compete()
+		self.Tint(msg.Time)
+	}
 	for _, e := range self.entities {
 		e.Update(msg.Time)
 	}
 }
 
+// Compete sums up the biomass of the living neighbours shading each plant
+// within its competition radius
+func (self *PlantSpawningSystem) Compete() {
+	var spacial *SpacialSystem
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *SpacialSystem:
+			spacial = sys
+		}
+	}
+	if spacial == nil {
+		return
+	}
+	byID := make(map[uint64]*plants.Plant, len(self.entities))
+	for _, e := range self.entities {
+		byID[e.BasicEntity.ID()] = e
+	}
+	for _, e := range self.entities {
+		e.Shade = 0
+		if !e.IsAlive || e.CompetitionRadius <= 0 {
+			continue
+		}
+		centre := e.SpaceComponent.Center()
+		neighbours := spacial.InRadius(centre, e.CompetitionRadius*float32(config.SpriteWidth), func(aabb engo.AABBer) bool {
+			tile, ok := aabb.(*data.Tile)
+			return ok && tile.Layer == plantLayer && tile.BasicEntity.ID() != e.BasicEntity.ID()
+		})
+		for _, n := range neighbours {
+			if neighbour, ok := byID[n.(*data.Tile).BasicEntity.ID()]; ok && neighbour.IsAlive {
+				e.Shade += e.Shading(neighbour)
+			}
+		}
+	}
+}
+
+// Tint colours the plants by the season, browning as they stop growing
+func (self *PlantSpawningSystem) Tint(t *calendar.Time) {
+	for _, e := range self.entities {
+		if e.Tile != nil && e.RenderComponent != nil {
+			e.RenderComponent.Color = e.Tint(t)
+		}
+	}
+}
+
+// HandleSeedMessage scatters the seeds of the plant, if it's due to
+func (self *PlantSpawningSystem) HandleSeedMessage(m engo.Message) {
+	msg, ok := m.(messages.SeedMessage)
+	if !ok {
+		return
+	}
+	plant := self.Get(msg.EntityID)
+	if plant == nil || !plant.CanSeed(msg.Time) {
+		return
+	}
+	plant.LastSeeded = msg.Time.SecondsSinceBeginningOfTime
+	self.Scatter(plant)
+}
+
+// Scatter drops the seeds of the plant on random tiles within its seed radius.
+// Those landing on free suitable ground take root as seedlings, it tells how
+// many did.
+func (self *PlantSpawningSystem) Scatter(plant *plants.Plant) int {
+	var worldTiles *WorldTilesSystem
+	var spacial *SpacialSystem
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *WorldTilesSystem:
+			worldTiles = sys
+		case *SpacialSystem:
+			spacial = sys
+		}
+	}
+	if worldTiles == nil || spacial == nil {
+		return 0
+	}
+	x, y := util.ToGridIndex(plant.SpaceComponent.Position.X, plant.SpaceComponent.Position.Y)
+	radius := int(plant.SeedRadius)
+	rooted := 0
+	for i := 0; i < plant.Seeds; i++ {
+		dx, dy := self.rand.Intn(2*radius+1)-radius, self.rand.Intn(2*radius+1)-radius
+		if dx*dx+dy*dy > radius*radius {
+			// Blown too far
+			continue
+		}
+		if !self.canTakeRoot(plant, x+dx, y+dy, worldTiles, spacial) {
+			continue
+		}
+		engo.Mailbox.Dispatch(messages.NewPlantMessage{
+			PlantID: plant.SeedlingID,
+			Point:   util.ToPoint(x+dx, y+dy),
+		})
+		rooted++
+	}
+	return rooted
+}
+
+// canTakeRoot tells whether a seed of the plant grows at the tile: on a soil
+// it likes, clear of obstacles and other plants, and not too crowded
+func (self *PlantSpawningSystem) canTakeRoot(plant *plants.Plant, x, y int, worldTiles *WorldTilesSystem, spacial *SpacialSystem) bool {
+	ground := worldTiles.GroundAt(x, y)
+	if ground == nil || ground.Object == nil || !util.ContainsStr(plant.Soils, ground.Object.Terrain) {
+		return false
+	}
+	if _, ok := worldTiles.MovementCost(x, y); !ok {
+		return false
+	}
+	centre := *util.ToPoint(x, y)
+	centre.Add(engo.Point{float32(config.SpriteWidth) / 2, float32(config.SpriteHeight) / 2})
+	neighbours := spacial.InRadius(centre, 1.5*float32(config.SpriteWidth), func(aabb engo.AABBer) bool {
+		tile, ok := aabb.(*data.Tile)
+		return ok && tile.Layer == plantLayer
+	})
+	for _, n := range neighbours {
+		if centre.PointDistance(n.(*data.Tile).SpaceComponent.Center()) < 1 {
+			// Taken
+			return false
+		}
+	}
+	return len(neighbours) <= plant.MaxNeighbours
+}
+
+func (self *PlantSpawningSystem) HandleWorldSeedMessage(m engo.Message) {
+	msg, ok := m.(messages.WorldSeedMessage)
+	if !ok {
+		return
+	}
+	self.rand = util.NewRand(msg.Seed, "plants")
+}
+
 func (self *PlantSpawningSystem) HandleControlMessage(m engo.Message) {
 	msg, ok := m.(messages.ControlMessage)
 	if !ok {
@@ -155,9 +334,36 @@ func (self *PlantSpawningSystem) UpdateSave(saveFile *save.SaveFile) {
 	}
 }
 
+// UpdateMap adds the plants to an exported map, with their current growth
+func (self *PlantSpawningSystem) UpdateMap(m *tiled.Map) {
+	for _, e := range self.entities {
+		entityID := e.BasicEntity.ID()
+		if _, ok := m.SeenEntityIDs[entityID]; ok {
+			continue
+		}
+		x, y := util.ToGridIndex(e.SpaceComponent.Position.X, e.SpaceComponent.Position.Y)
+		m.Entities = append(m.Entities, tiled.Entity{
+			Kind:     tiled.PlantKind,
+			ID:       e.ID,
+			ObjectID: e.Tile.ObjectID,
+			X:        x,
+			Y:        y,
+			Properties: map[string]interface{}{
+				"growth":    e.Growth,
+				"nutrients": e.Nutrients,
+			},
+		})
+		m.SeenEntityIDs[entityID] = struct{}{}
+	}
+}
+
 func (self *PlantSpawningSystem) LoadSave(saveFile *save.SaveFile) {
 	log.Printf("[PlantSpawningSystem] Plants in the save file: %d\n", len(saveFile.Plants))
 	for _, c := range saveFile.Plants {
 		self.Add(c)
+		if saveFile.Version < 1 {
+			// Saved before plants took up the soil
+			c.Nutrients = c.Growth * c.NutrientUptake
+		}
 	}
 }
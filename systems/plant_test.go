@@ -0,0 +1,47 @@
+package systems
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"gogame/life/plants"
+	"gogame/util"
+	"testing"
+)
+
+func TestCompete(t *testing.T) {
+	engo.Mailbox = &engo.MessageManager{}
+	world := &ecs.World{}
+	spacial := &SpacialSystem{}
+	world.AddSystem(spacial)
+	plantSystem := &PlantSpawningSystem{}
+	world.AddSystem(plantSystem)
+
+	add := func(x, y int) *plants.Plant {
+		plant := NewPlant(3, util.ToPoint(x, y))
+		plantSystem.Add(plant)
+		spacial.Add(plant.Tile.BasicEntity, plant.Tile)
+		return plant
+	}
+	// A dense 3x3 patch and a plant on its own
+	var patch []*plants.Plant
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			patch = append(patch, add(x, y))
+		}
+	}
+	isolated := add(10, 10)
+
+	plantSystem.Compete()
+	for _, plant := range patch {
+		if plant.Shade <= 0 {
+			t.Errorf("the plant at %v in the patch isn't shaded", plant.SpaceComponent.Position)
+		}
+	}
+	// The middle of the patch has the most neighbours
+	if middle, corner := patch[4], patch[0]; middle.Shade <= corner.Shade {
+		t.Errorf("the middle of the patch is shaded %v, no more than a corner with %v", middle.Shade, corner.Shade)
+	}
+	if isolated.Shade != 0 {
+		t.Errorf("the isolated plant is shaded %v", isolated.Shade)
+	}
+}
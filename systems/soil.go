@@ -0,0 +1,333 @@
+package systems
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"gogame/calendar"
+	"gogame/config"
+	"gogame/data"
+	"gogame/messages"
+	"gogame/save"
+	"gogame/terrain"
+	"gogame/util"
+	"image/color"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Initial soil of the ground by its terrain, any other is barren and dry
+var terrainSoil = map[string]data.Soil{
+	terrain.WaterDeep: {Moisture: 1},
+	terrain.Water:     {Moisture: 1},
+	terrain.Sand:      {Fertility: 0.1, Moisture: 0.2},
+	terrain.DirtBrown: {Fertility: 0.5, Moisture: 0.3},
+	terrain.Grass:     {Fertility: 0.6, Moisture: 0.5},
+	terrain.GrassDark: {Fertility: 0.7, Moisture: 0.7},
+	terrain.RockGray:  {Fertility: 0.05, Moisture: 0.1},
+	terrain.Snow:      {Moisture: 0.8},
+}
+
+// The weather and the soil, per hour
+const (
+	rainChance         = 0.1   // Of a rain in an hour
+	rainfall           = 0.1   // Moisture a rain brings
+	evaporation        = 0.007 // Moisture lost
+	moistureDiffusion  = 0.1   // Share of the difference with the neighbours evened out
+	fertilityDiffusion = 0.01
+)
+
+type soilOverlay uint8
+
+const (
+	noOverlay soilOverlay = iota
+	fertilityOverlay
+	moistureOverlay
+)
+
+func (o soilOverlay) String() string {
+	return [...]string{"off", "fertility", "moisture"}[o]
+}
+
+// soilShape shades a tile of the soil overlay
+type soilShape struct {
+	ecs.BasicEntity
+	*common.RenderComponent
+	*common.SpaceComponent
+}
+
+// SoilSystem keeps the fertility and the moisture of the soil under each
+// ground tile. Plants take them up as they grow, rotting plants return the
+// nutrients, rain wets the soil and both slowly spread to the neighbours.
+type SoilSystem struct {
+	world *ecs.World
+	soil  map[gridIndex]*data.Soil
+	// Where the soil lies by the ground tile it was laid under
+	ground map[uint64]gridIndex
+	// Water keeps the soil around it moist
+	water map[gridIndex]bool
+	rand  *rand.Rand
+
+	overlay soilOverlay
+	shapes  map[gridIndex]*soilShape
+}
+
+func (self *SoilSystem) New(w *ecs.World) {
+	self.world = w
+	self.soil = make(map[gridIndex]*data.Soil)
+	self.ground = make(map[uint64]gridIndex)
+	self.water = make(map[gridIndex]bool)
+	self.shapes = make(map[gridIndex]*soilShape)
+	self.rand = util.NewRand(0, "soil")
+
+	engo.Mailbox.Listen(messages.NutrientsMessageType, self.HandleNutrientsMessage)
+	engo.Mailbox.Listen(messages.TimeSecondPassedMessageType, self.HandleTimeSecondPassedMessage)
+	engo.Mailbox.Listen(messages.ControlMessageType, self.HandleControlMessage)
+	engo.Mailbox.Listen(messages.WorldSeedMessageType, self.HandleWorldSeedMessage)
+}
+
+// Add lays the soil under a ground tile, as its terrain makes it
+func (self *SoilSystem) Add(ground *data.Tile) {
+	index := tileGridIndex(ground)
+	if _, ok := self.soil[index]; ok {
+		return
+	}
+	self.soil[index] = &data.Soil{}
+	self.ground[ground.BasicEntity.ID()] = index
+	self.Replace(ground)
+}
+
+// Replace lays the soil anew under a ground tile whose terrain changed
+func (self *SoilSystem) Replace(ground *data.Tile) {
+	index := tileGridIndex(ground)
+	soil, ok := self.soil[index]
+	if !ok {
+		return
+	}
+	// In place, plants on it keep pointing to it
+	*soil = terrainSoil[ground.Object.Terrain]
+	soil.X, soil.Y = index.X, index.Y
+	isWater := ground.Object.Terrain == terrain.Water || ground.Object.Terrain == terrain.WaterDeep
+	if isWater {
+		self.water[index] = true
+	} else {
+		delete(self.water, index)
+	}
+}
+
+func (self *SoilSystem) Update(dt float32) {}
+
+// Remove takes the soil away with the ground tile it was laid under
+func (self *SoilSystem) Remove(e ecs.BasicEntity) {
+	index, ok := self.ground[e.ID()]
+	if !ok {
+		return
+	}
+	delete(self.ground, e.ID())
+	delete(self.soil, index)
+	delete(self.water, index)
+	if shape, ok := self.shapes[index]; ok {
+		delete(self.shapes, index)
+		self.world.RemoveEntity(shape.BasicEntity)
+	}
+}
+
+// At returns the soil at the given column and row, if any
+func (self *SoilSystem) At(x, y int) *data.Soil {
+	return self.soil[gridIndex{x, y}]
+}
+
+// Average returns the mean fertility and moisture of the soil of the world
+func (self *SoilSystem) Average() (float32, float32) {
+	if len(self.soil) == 0 {
+		return 0, 0
+	}
+	var fertility, moisture float32
+	for _, s := range self.soil {
+		fertility += s.Fertility
+		moisture += s.Moisture
+	}
+	n := float32(len(self.soil))
+	return fertility / n, moisture / n
+}
+
+// weather rains now and then, and dries the soil out
+func (self *SoilSystem) weather() {
+	rain := self.rand.Float32() < rainChance
+	for index, s := range self.soil {
+		if self.water[index] {
+			s.Moisture = 1
+			continue
+		}
+		if rain {
+			s.Enrich(0, rainfall)
+		}
+		s.Deplete(0, evaporation)
+	}
+}
+
+// diffuse evens out the soil of neighbouring tiles a bit. Water wets the
+// shores but doesn't wash the nutrients away.
+func (self *SoilSystem) diffuse() {
+	type change struct{ fertility, moisture float32 }
+	changes := make(map[gridIndex]change, len(self.soil))
+	for index, s := range self.soil {
+		var fertility, moisture float32
+		n, land := 0, 0
+		for _, d := range []gridIndex{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			neighbourIndex := gridIndex{index.X + d.X, index.Y + d.Y}
+			neighbour, ok := self.soil[neighbourIndex]
+			if !ok {
+				continue
+			}
+			moisture += neighbour.Moisture
+			n++
+			if !self.water[neighbourIndex] {
+				fertility += neighbour.Fertility
+				land++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		c := change{moisture: (moisture/float32(n) - s.Moisture) * moistureDiffusion}
+		if land > 0 && !self.water[index] {
+			c.fertility = (fertility/float32(land) - s.Fertility) * fertilityDiffusion
+		}
+		changes[index] = c
+	}
+	for index, c := range changes {
+		self.soil[index].Enrich(c.fertility, c.moisture)
+	}
+}
+
+func (self *SoilSystem) HandleTimeSecondPassedMessage(m engo.Message) {
+	msg, ok := m.(messages.TimeSecondPassedMessage)
+	if !ok {
+		return
+	}
+	if msg.Time.SecondsSinceBeginningOfTime%calendar.SecondsPerHour != 0 {
+		return
+	}
+	self.weather()
+	self.diffuse()
+	self.shade()
+}
+
+func (self *SoilSystem) HandleNutrientsMessage(m engo.Message) {
+	msg, ok := m.(messages.NutrientsMessage)
+	if !ok {
+		return
+	}
+	if s := self.At(util.ToGridIndex(msg.Point.X, msg.Point.Y)); s != nil {
+		s.Enrich(msg.Amount, 0)
+	}
+}
+
+// HandleControlMessage switches the overlay between the fertility, the
+// moisture and none
+func (self *SoilSystem) HandleControlMessage(m engo.Message) {
+	msg, ok := m.(messages.ControlMessage)
+	if !ok || msg.Action != "ToggleSoilOverlay" {
+		return
+	}
+	self.overlay = (self.overlay + 1) % (moistureOverlay + 1)
+	if self.overlay == noOverlay {
+		self.hide()
+	} else {
+		self.shade()
+	}
+	overlay := self.overlay
+	engo.Mailbox.Dispatch(messages.HUDTextUpdateMessage{
+		Name:      "EventMessage",
+		HideAfter: 3 * time.Second,
+		GetText: func() string {
+			return "Soil overlay: " + overlay.String()
+		},
+	})
+}
+
+// shade colours the tiles of the overlay by the field it shows
+func (self *SoilSystem) shade() {
+	if self.overlay == noOverlay {
+		return
+	}
+	for index, s := range self.soil {
+		shape, ok := self.shapes[index]
+		if !ok {
+			shape = self.addShape(index)
+		}
+		// The richer the soil, the deeper the shade
+		if self.overlay == fertilityOverlay {
+			shape.RenderComponent.Color = color.RGBA{0, 255, 0, uint8(s.Fertility * 160)}
+		} else {
+			shape.RenderComponent.Color = color.RGBA{0, 0, 255, uint8(s.Moisture * 160)}
+		}
+	}
+}
+
+func (self *SoilSystem) addShape(index gridIndex) *soilShape {
+	shape := &soilShape{BasicEntity: ecs.NewBasic()}
+	shape.SpaceComponent = &common.SpaceComponent{
+		Position: *util.ToPoint(index.X, index.Y),
+		Width:    float32(config.SpriteWidth),
+		Height:   float32(config.SpriteHeight),
+	}
+	shape.RenderComponent = &common.RenderComponent{Drawable: common.Rectangle{}}
+	shape.RenderComponent.SetZIndex(9)
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *common.RenderSystem:
+			sys.Add(&shape.BasicEntity, shape.RenderComponent, shape.SpaceComponent)
+		}
+	}
+	self.shapes[index] = shape
+	return shape
+}
+
+func (self *SoilSystem) hide() {
+	for index, shape := range self.shapes {
+		self.world.RemoveEntity(shape.BasicEntity)
+		delete(self.shapes, index)
+	}
+}
+
+func (self *SoilSystem) HandleWorldSeedMessage(m engo.Message) {
+	msg, ok := m.(messages.WorldSeedMessage)
+	if !ok {
+		return
+	}
+	self.rand = util.NewRand(msg.Seed, "soil")
+}
+
+func (self *SoilSystem) UpdateSave(saveFile *save.SaveFile) {
+	saveFile.Soil = nil
+	for _, s := range self.soil {
+		saveFile.Soil = append(saveFile.Soil, s)
+	}
+	sort.Slice(saveFile.Soil, func(i, j int) bool {
+		a, b := saveFile.Soil[i], saveFile.Soil[j]
+		return a.X < b.X || (a.X == b.X && a.Y < b.Y)
+	})
+}
+
+// LoadSave restores the soil, once the ground is laid
+func (self *SoilSystem) LoadSave(saveFile *save.SaveFile) {
+	if saveFile.Version < 1 {
+		log.Println("[SoilSystem] Saved before the soil, keeping the soil of the terrain")
+		return
+	}
+	log.Printf("[SoilSystem] Soil tiles in the save file: %d\n", len(saveFile.Soil))
+	for _, s := range saveFile.Soil {
+		index := gridIndex{s.X, s.Y}
+		if soil, ok := self.soil[index]; ok {
+			// Plants on it keep pointing to it
+			*soil = *s
+			continue
+		}
+		soil := *s
+		self.soil[index] = &soil
+	}
+}
@@ -0,0 +1,75 @@
+package systems
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"gogame/data"
+	"gogame/save"
+	"gogame/terrain"
+	"gogame/util"
+	"testing"
+)
+
+// Objects of the ground
+const (
+	grassObject = 20
+	waterObject = 18
+)
+
+// newTestSoilWorld is a world of ground tiles and their soil, listening on a
+// fresh mailbox
+func newTestSoilWorld() (*ecs.World, *SoilSystem, *WorldTilesSystem) {
+	engo.Mailbox = &engo.MessageManager{}
+	world := &ecs.World{}
+	soil := &SoilSystem{}
+	worldTiles := &WorldTilesSystem{}
+	world.AddSystem(soil)
+	world.AddSystem(worldTiles)
+	return world, soil, worldTiles
+}
+
+func TestSoilRemovedWithGround(t *testing.T) {
+	world, soil, worldTiles := newTestSoilWorld()
+	grass := NewTile(grassObject, util.ToPoint(1, 1), 0, nil)
+	water := NewTile(waterObject, util.ToPoint(2, 1), 0, nil)
+	worldTiles.Add(grass)
+	worldTiles.Add(water)
+	if soil.At(1, 1) == nil || !soil.water[gridIndex{2, 1}] {
+		t.Fatal("no soil is laid under the ground")
+	}
+
+	world.RemoveEntity(*grass.BasicEntity)
+	world.RemoveEntity(*water.BasicEntity)
+	if soil.At(1, 1) != nil || soil.At(2, 1) != nil {
+		t.Error("the soil is left where the ground was removed")
+	}
+	if soil.water[gridIndex{2, 1}] {
+		t.Error("the removed water still wets the soil around")
+	}
+	if fertility, moisture := soil.Average(); fertility != 0 || moisture != 0 {
+		t.Errorf("the removed soil still counts, %v fertility and %v moisture on average", fertility, moisture)
+	}
+}
+
+func TestSoilLoadSave(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		want    float32
+	}{
+		{"saved before the soil", 0, terrainSoil[terrain.Grass].Fertility},
+		{"saved with the soil", save.Version, 0.9},
+	}
+	for _, test := range tests {
+		_, soil, worldTiles := newTestSoilWorld()
+		worldTiles.Add(NewTile(grassObject, util.ToPoint(1, 1), 0, nil))
+		laid := soil.At(1, 1)
+		soil.LoadSave(&save.SaveFile{
+			Version: test.version,
+			Soil:    []*data.Soil{{X: 1, Y: 1, Fertility: 0.9}},
+		})
+		if got := soil.At(1, 1); got != laid || got.Fertility != test.want {
+			t.Errorf("%s: the soil has %v fertility, want %v in the same soil", test.name, got.Fertility, test.want)
+		}
+	}
+}
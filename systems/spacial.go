@@ -6,35 +6,146 @@ import (
 	"github.com/EngoEngine/engo"
 	"gogame/config"
 	"gogame/messages"
+	"gogame/util"
 	"log"
+	"sort"
 	"time"
 	//"math/rand"
 )
 
 type SpacialSystem struct {
 	world    *ecs.World
+	bounds   engo.AABB
 	entities *engo.Quadtree
+	// Entities in the quadtree by their IDs
+	indexed map[uint64]*spacialEntity
+}
+
+// spacialEntity remembers the bounds an entity was indexed with, the quadtree
+// can only find it by those once the entity has moved
+type spacialEntity struct {
+	engo.AABBer
+	aabb engo.AABB
+}
+
+func (self *spacialEntity) AABB() engo.AABB {
+	return self.aabb
 }
 
 func (self *SpacialSystem) New(world *ecs.World) {
 	self.world = world
-	self.entities = engo.NewQuadtree(
-		engo.AABB{
-			Min: engo.Point{0, 0},
-			Max: engo.Point{float32(config.SpriteWidth * 256), float32(config.SpriteHeight * 256)}},
-		true, 16)
+	self.indexed = make(map[uint64]*spacialEntity)
+	self.resize(util.WorldSize{Width: config.WorldWidth, Height: config.WorldHeight}.Bounds())
 
 	engo.Mailbox.Listen(messages.SpacialRequestMessageType, self.HandleSpacialRequestMessage)
+	engo.Mailbox.Listen(messages.TileMoveMessageType, self.HandleTileMoveMessage)
+	engo.Mailbox.Listen(messages.PathRequestMessageType, self.HandlePathRequestMessage)
+	engo.Mailbox.Listen(messages.WorldSizeMessageType, self.HandleWorldSizeMessage)
 }
 
-func (self *SpacialSystem) Add(e engo.AABBer) {
-	self.entities.Insert(e)
+// resize rebuilds the quadtree with the given bounds, grown to fit all of the
+// entities already indexed
+func (self *SpacialSystem) resize(bounds engo.AABB) {
+	ids := make([]uint64, 0, len(self.indexed))
+	for id, e := range self.indexed {
+		ids = append(ids, id)
+		bounds = grow(bounds, e.aabb)
+	}
+	if self.entities != nil && bounds == self.bounds {
+		return
+	}
+	self.bounds = bounds
+	self.entities = engo.NewQuadtree(bounds, true, 16)
+	// Results of queries come in the order of insertion, keep it reproducible
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		self.entities.Insert(self.indexed[id])
+	}
+}
+
+// fit makes sure that the quadtree covers the bounds of an entity, entities
+// outside of it could get lost
+func (self *SpacialSystem) fit(aabb engo.AABB) {
+	if contains(self.bounds, aabb) {
+		return
+	}
+	log.Printf("[SpacialSystem] %v is out of the bounds %v, growing them", aabb, self.bounds)
+	self.resize(grow(self.bounds, aabb))
+}
+
+func contains(outer engo.AABB, inner engo.AABB) bool {
+	return outer.Min.X <= inner.Min.X && outer.Min.Y <= inner.Min.Y &&
+		outer.Max.X >= inner.Max.X && outer.Max.Y >= inner.Max.Y
+}
+
+// grow doubles the bounds towards the given AABB until they contain it, so
+// that an entity wandering off doesn't rebuild the quadtree at every step
+func grow(bounds engo.AABB, aabb engo.AABB) engo.AABB {
+	for !contains(bounds, aabb) {
+		width := bounds.Max.X - bounds.Min.X
+		if width <= 0 {
+			width = float32(config.SpriteWidth)
+		}
+		height := bounds.Max.Y - bounds.Min.Y
+		if height <= 0 {
+			height = float32(config.SpriteHeight)
+		}
+		if aabb.Min.X < bounds.Min.X {
+			bounds.Min.X -= width
+		}
+		if aabb.Max.X > bounds.Max.X {
+			bounds.Max.X += width
+		}
+		if aabb.Min.Y < bounds.Min.Y {
+			bounds.Min.Y -= height
+		}
+		if aabb.Max.Y > bounds.Max.Y {
+			bounds.Max.Y += height
+		}
+	}
+	return bounds
+}
+
+func (self *SpacialSystem) Add(basic *ecs.BasicEntity, e engo.AABBer) {
+	if _, ok := self.indexed[basic.ID()]; ok {
+		self.Move(*basic)
+		return
+	}
+	entity := &spacialEntity{AABBer: e, aabb: e.AABB()}
+	self.fit(entity.aabb)
+	self.indexed[basic.ID()] = entity
+	self.entities.Insert(entity)
 }
 
 func (self *SpacialSystem) Update(dt float32) {}
 
 func (self *SpacialSystem) Remove(e ecs.BasicEntity) {
-	// TODO
+	entity, ok := self.indexed[e.ID()]
+	if !ok {
+		return
+	}
+	self.entities.Remove(entity)
+	delete(self.indexed, e.ID())
+}
+
+// Move reindexes an entity at its current bounds
+func (self *SpacialSystem) Move(e ecs.BasicEntity) {
+	entity, ok := self.indexed[e.ID()]
+	if !ok {
+		return
+	}
+	aabb := entity.AABBer.AABB()
+	if aabb == entity.aabb {
+		return
+	}
+	self.entities.Remove(entity)
+	entity.aabb = aabb
+	if !contains(self.bounds, aabb) {
+		// Rebuilt with the entity at its new bounds
+		self.fit(aabb)
+		return
+	}
+	self.entities.Insert(entity)
 }
 
 func (self *SpacialSystem) Query(aabb engo.AABB, filter func(aabb engo.AABBer) bool) []engo.AABBer {
@@ -43,7 +154,71 @@ func (self *SpacialSystem) Query(aabb engo.AABB, filter func(aabb engo.AABBer) b
 		RemoveAfter: 3 * time.Second,
 		Color:       "red",
 	})
-	return self.entities.Retrieve(aabb, filter)
+	return self.retrieve(aabb, filter)
+}
+
+func (self *SpacialSystem) retrieve(aabb engo.AABB, filter func(aabb engo.AABBer) bool) []engo.AABBer {
+	found := self.entities.Retrieve(aabb, func(e engo.AABBer) bool {
+		return filter == nil || filter(e.(*spacialEntity).AABBer)
+	})
+	for i, e := range found {
+		found[i] = e.(*spacialEntity).AABBer
+	}
+	return found
+}
+
+// Within returns the entities overlapping the AABB which pass the filter, the
+// nearest to `from` first
+func (self *SpacialSystem) Within(aabb engo.AABB, from engo.Point, filter func(aabb engo.AABBer) bool) []engo.AABBer {
+	return sortByDistance(self.retrieve(aabb, filter), from)
+}
+
+// InRadius returns the entities with their centres within the radius of the
+// point which pass the filter, the nearest first
+func (self *SpacialSystem) InRadius(center engo.Point, radius float32, filter func(aabb engo.AABBer) bool) []engo.AABBer {
+	aabb := engo.AABB{
+		Min: engo.Point{center.X - radius, center.Y - radius},
+		Max: engo.Point{center.X + radius, center.Y + radius},
+	}
+	found := self.Within(aabb, center, filter)
+	for i, e := range found {
+		if c := centre(e); c.PointDistance(center) > radius {
+			return found[:i]
+		}
+	}
+	return found
+}
+
+// Nearest returns up to n entities nearest to the point within the radius
+// which pass the filter, the nearest first. It finds nothing for n <= 0.
+func (self *SpacialSystem) Nearest(center engo.Point, n int, radius float32, filter func(aabb engo.AABBer) bool) []engo.AABBer {
+	if n <= 0 {
+		return nil
+	}
+	found := self.InRadius(center, radius, filter)
+	if len(found) > n {
+		return found[:n]
+	}
+	return found
+}
+
+func centre(e engo.AABBer) engo.Point {
+	aabb := e.AABB()
+	return engo.Point{(aabb.Min.X + aabb.Max.X) / 2, (aabb.Min.Y + aabb.Max.Y) / 2}
+}
+
+// sortByDistance sorts the entities by the distance of their centres from the
+// point, keeping the order of the quadtree for equally distant ones
+func sortByDistance(entities []engo.AABBer, from engo.Point) []engo.AABBer {
+	distances := make(map[engo.AABBer]float32, len(entities))
+	for _, e := range entities {
+		c := centre(e)
+		distances[e] = c.PointDistance(from)
+	}
+	sort.SliceStable(entities, func(i, j int) bool {
+		return distances[entities[i]] < distances[entities[j]]
+	})
+	return entities
 }
 
 func (self *SpacialSystem) HandleSpacialRequestMessage(m engo.Message) {
@@ -53,15 +228,41 @@ func (self *SpacialSystem) HandleSpacialRequestMessage(m engo.Message) {
 		return
 	}
 	foundEntities := self.Query(msg.Aabb, msg.Filter)
+	if msg.From != nil {
+		foundEntities = sortByDistance(foundEntities, *msg.From)
+	}
+	if msg.Limit > 0 && len(foundEntities) > msg.Limit {
+		foundEntities = foundEntities[:msg.Limit]
+	}
 	engo.Mailbox.Dispatch(messages.DisplayDebugAABBMessage{
 		Aabbers:     foundEntities,
 		RemoveAfter: 3 * time.Second,
 		Color:       "green",
 	})
 	engo.Mailbox.Dispatch(messages.SpacialResponseMessage{
-		Aabb:     msg.Aabb,
-		EntityID: msg.EntityID,
-		Filter:   msg.Filter,
-		Result:   foundEntities,
+		Aabb:          msg.Aabb,
+		EntityID:      msg.EntityID,
+		EventID:       msg.EventID,
+		BasicEntityID: int(msg.EntityID),
+		Filter:        msg.Filter,
+		Result:        foundEntities,
 	})
 }
+
+func (self *SpacialSystem) HandleTileMoveMessage(m engo.Message) {
+	msg, ok := m.(messages.TileMoveMessage)
+	if !ok {
+		return
+	}
+	if msg.Entity != nil {
+		self.Move(*msg.Entity)
+	}
+}
+
+func (self *SpacialSystem) HandleWorldSizeMessage(m engo.Message) {
+	msg, ok := m.(messages.WorldSizeMessage)
+	if !ok {
+		return
+	}
+	self.resize(msg.Size.Bounds())
+}
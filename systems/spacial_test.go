@@ -0,0 +1,173 @@
+package systems
+
+import (
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"gogame/config"
+	"gogame/data"
+	"gogame/messages"
+	"gogame/util"
+	"testing"
+)
+
+type spacialTestEntity struct {
+	ecs.BasicEntity
+	*common.SpaceComponent
+}
+
+func newSpacialTestEntity(x, y float32) *spacialTestEntity {
+	return &spacialTestEntity{
+		BasicEntity:    ecs.NewBasic(),
+		SpaceComponent: &common.SpaceComponent{Position: engo.Point{X: x, Y: y}, Width: 32, Height: 32},
+	}
+}
+
+func newTestSpacialSystem() *SpacialSystem {
+	if engo.Mailbox == nil {
+		engo.Mailbox = &engo.MessageManager{}
+	}
+	spacial := &SpacialSystem{}
+	spacial.New(&ecs.World{})
+	return spacial
+}
+
+// found tells whether the entity is among those centred within a tile of the point
+func found(spacial *SpacialSystem, e *spacialTestEntity, x, y float32) bool {
+	for _, f := range spacial.InRadius(engo.Point{X: x, Y: y}, 32, nil) {
+		if f == e {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSpacialMove(t *testing.T) {
+	spacial := newTestSpacialSystem()
+	e := newSpacialTestEntity(64, 64)
+	spacial.Add(&e.BasicEntity, e)
+	if !found(spacial, e, 80, 80) {
+		t.Fatal("the entity isn't found where it was added")
+	}
+
+	e.Position = engo.Point{X: 320, Y: 320}
+	spacial.Move(e.BasicEntity)
+	if !found(spacial, e, 336, 336) {
+		t.Error("the entity isn't found where it moved to")
+	}
+	if found(spacial, e, 80, 80) {
+		t.Error("the entity is still found where it moved from")
+	}
+}
+
+func TestSpacialRemove(t *testing.T) {
+	spacial := newTestSpacialSystem()
+	e := newSpacialTestEntity(64, 64)
+	spacial.Add(&e.BasicEntity, e)
+	spacial.Remove(e.BasicEntity)
+	if found(spacial, e, 80, 80) {
+		t.Error("the removed entity is still found")
+	}
+}
+
+func TestSpacialGrow(t *testing.T) {
+	spacial := newTestSpacialSystem()
+	inside := newSpacialTestEntity(64, 64)
+	spacial.Add(&inside.BasicEntity, inside)
+
+	x, y := spacial.bounds.Max.X+1000, spacial.bounds.Min.Y-1000
+	outside := newSpacialTestEntity(x, y)
+	spacial.Add(&outside.BasicEntity, outside)
+	if !found(spacial, outside, x+16, y+16) {
+		t.Error("the entity out of the initial bounds isn't found")
+	}
+	if !found(spacial, inside, 80, 80) {
+		t.Error("the entity within the initial bounds isn't found once they grew")
+	}
+}
+
+// newTestTilesWorld is a world of tiles indexed by the spacial system, which
+// listen on a fresh mailbox
+func newTestTilesWorld() (*SpacialSystem, *WorldTilesSystem) {
+	engo.Mailbox = &engo.MessageManager{}
+	world := &ecs.World{}
+	spacial := &SpacialSystem{}
+	worldTiles := &WorldTilesSystem{}
+	world.AddSystem(spacial)
+	world.AddSystem(worldTiles)
+	return spacial, worldTiles
+}
+
+// foundBy tells whether each of the queries finds the tile around the point
+func foundBy(spacial *SpacialSystem, tile *data.Tile, p engo.Point, filter func(engo.AABBer) bool) (within, inRadius, nearest bool) {
+	aabb := engo.AABB{
+		Min: engo.Point{X: p.X - 32, Y: p.Y - 32},
+		Max: engo.Point{X: p.X + 32, Y: p.Y + 32},
+	}
+	for _, f := range spacial.Within(aabb, p, filter) {
+		within = within || f == tile
+	}
+	for _, f := range spacial.InRadius(p, 32, filter) {
+		inRadius = inRadius || f == tile
+	}
+	for _, f := range spacial.Nearest(p, 1, 32, filter) {
+		nearest = nearest || f == tile
+	}
+	return
+}
+
+func assertFound(t *testing.T, spacial *SpacialSystem, tile *data.Tile, p engo.Point, filter func(engo.AABBer) bool, want bool, what string) {
+	t.Helper()
+	within, inRadius, nearest := foundBy(spacial, tile, p, filter)
+	if within != want || inRadius != want || nearest != want {
+		t.Errorf("%s: found by Within %v, InRadius %v, Nearest %v, want %v", what, within, inRadius, nearest, want)
+	}
+}
+
+func TestSpacialTileRemoveMessage(t *testing.T) {
+	spacial, worldTiles := newTestTilesWorld()
+	tile := NewTile(1, util.ToPoint(2, 2), plantLayer, nil)
+	worldTiles.Add(tile)
+	centre := tile.SpaceComponent.Center()
+	assertFound(t, spacial, tile, centre, nil, true, "added")
+
+	engo.Mailbox.Dispatch(messages.TileRemoveMessage{Entity: tile.BasicEntity})
+	assertFound(t, spacial, tile, centre, nil, false, "removed")
+}
+
+func TestSpacialCreatureMovement(t *testing.T) {
+	spacial, worldTiles := newTestTilesWorld()
+	c := NewCreature(1, util.ToPoint(2, 2))
+	worldTiles.Add(c.Tile)
+	from := c.SpaceComponent.Center()
+	target := NewTile(1, util.ToPoint(8, 2), plantLayer, nil)
+	worldTiles.Add(target)
+	isCreature := func(aabb engo.AABBer) bool { return aabb == c.Tile }
+
+	// Walks 6 tiles, sending TileMoveMessages on the way
+	c.MovementTarget = target
+	c.Move(6 * float32(config.SpriteWidth) / c.MovementSpeed)
+	if c.MovementTarget != nil {
+		t.Fatalf("the creature didn't reach its target, it's at %v", c.SpaceComponent.Position)
+	}
+	assertFound(t, spacial, c.Tile, target.SpaceComponent.Center(), isCreature, true, "where it walked to")
+	assertFound(t, spacial, c.Tile, from, isCreature, false, "where it walked from")
+}
+
+func TestSpacialReplaceObject(t *testing.T) {
+	spacial, worldTiles := newTestTilesWorld()
+	tile := NewTile(1, util.ToPoint(2, 2), plantLayer, nil)
+	worldTiles.Add(tile)
+	centre := tile.SpaceComponent.Center()
+	isFood := func(aabb engo.AABBer) bool { return aabb.(*data.Tile).Object.ResourceID == 1 }
+	isObject := func(id int) func(engo.AABBer) bool {
+		return func(aabb engo.AABBer) bool { return aabb.(*data.Tile).ObjectID == id }
+	}
+	assertFound(t, spacial, tile, centre, isFood, true, "food before the replacement")
+
+	// An object which is no food
+	engo.Mailbox.Dispatch(messages.TileReplaceMessage{Entity: tile.BasicEntity, ObjectID: 6})
+	assertFound(t, spacial, tile, centre, isFood, false, "food after the replacement")
+	assertFound(t, spacial, tile, centre, isObject(6), true, "the new object")
+	assertFound(t, spacial, tile, centre, isObject(1), false, "the old object")
+}
@@ -0,0 +1,196 @@
+// Package stubworld stands in for the WorldTilesSystem, SpacialSystem and
+// CreatureSpawningSystem around a single creature, to tick its behavior tree
+// without running the whole simulation: a square of grass with food on it,
+// which answers the queries of the creature right away, the creature walking
+// straight to what it finds.
+package stubworld
+
+import (
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"gogame/assets"
+	"gogame/calendar"
+	"gogame/config"
+	"gogame/data"
+	"gogame/messages"
+	"gogame/systems"
+	"gogame/terrain"
+	"gogame/util"
+	"math/rand"
+	"sort"
+)
+
+const plantLayer = 2
+
+type World struct {
+	Creature *data.Creature
+	Tiles    []*data.Tile
+	radius   int
+	rand     *rand.Rand
+}
+
+// Point is the position of the tile at the offset from the middle of the world
+func (self *World) Point(x, y int) *engo.Point {
+	return &engo.Point{X: float32((self.radius + x) * config.SpriteWidth), Y: float32((self.radius + y) * config.SpriteHeight)}
+}
+
+// New puts a creature of the species in the middle of a square of grass
+// `radius` tiles around it. The random decisions of the world come from the
+// seed.
+func New(creatureID int, radius int, seed int64) *World {
+	self := &World{radius: radius, rand: util.NewRand(seed, "stubworld")}
+	size := 2*radius + 1
+	grass := assets.GetObjectByTerrain(terrain.Grass)
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			self.add(systems.NewTile(grass.ID, self.Point(x-radius, y-radius), 0, nil))
+		}
+	}
+
+	c := systems.NewCreature(creatureID, self.Point(0, 0))
+	c.Tree = assets.GetBehaviorTree(c.Behavior)
+	self.prepare(c.Tile)
+	self.Creature = c
+	return self
+}
+
+// AddPlants puts food the creature eats at the offsets from the middle, in
+// tiles, with the amount of food each
+func (self *World) AddPlants(offsets [][2]int, amount float32) {
+	var plant *data.Object
+	for _, o := range assets.ObjectById {
+		if util.ContainsInt(self.Creature.Eats, o.ResourceID) && (plant == nil || o.ID < plant.ID) {
+			plant = o
+		}
+	}
+	if plant == nil {
+		return
+	}
+	for _, o := range offsets {
+		tile := systems.NewTile(plant.ID, self.Point(o[0], o[1]), plantLayer, nil)
+		self.add(tile)
+		tile.AccessibleResource.Amount = amount
+	}
+}
+
+// Listen answers the messages of the creature on the mailbox
+func (self *World) Listen() {
+	engo.Mailbox.Listen(messages.SpacialRequestMessageType, self.HandleSpacialRequestMessage)
+	engo.Mailbox.Listen(messages.TileRemoveMessageType, self.HandleTileRemoveMessage)
+	engo.Mailbox.Listen(messages.TileReplaceMessageType, self.HandleTileReplaceMessage)
+}
+
+// Tick advances the time by a second and the creature with it
+func (self *World) Tick(t *calendar.Time, r *rand.Rand) {
+	t.AddSecond()
+	c := self.Creature
+	if t.IsSunrise() || t.IsSunset() {
+		c.ReactToDaylight(t)
+	}
+	c.UpdateActivity(t, r)
+	c.Update(1)
+}
+
+func (self *World) add(tile *data.Tile) {
+	self.prepare(tile)
+	self.Tiles = append(self.Tiles, tile)
+}
+
+// prepare sets up a tile the way the WorldTilesSystem would, without rendering
+func (self *World) prepare(tile *data.Tile) {
+	tile.Object = assets.GetObjectById(tile.ObjectID)
+	if tile.Object.ResourceID != 0 {
+		tile.Resource = assets.GetResourceByID(tile.Object.ResourceID)
+	}
+	tile.AccessibleResource = &data.AccessibleResource{ResourceID: tile.Object.ResourceID, Amount: tile.Object.Amount}
+	if len(tile.Object.Animations) > 1 {
+		animation := common.NewAnimationComponent(tile.Object.Spritesheet.Drawables(), 0.25)
+		tile.AnimationComponent = &animation
+		tile.AnimationComponent.AddAnimations(tile.Object.Animations)
+	}
+}
+
+// Plants returns the food left
+func (self *World) Plants() []*data.Tile {
+	var result []*data.Tile
+	for _, t := range self.Tiles {
+		if t.Layer != 0 {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// PlantAt returns the food at the offset from the middle, if it's still there
+func (self *World) PlantAt(x, y int) *data.Tile {
+	for _, t := range self.Plants() {
+		if t.SpaceComponent.Position == *self.Point(x, y) {
+			return t
+		}
+	}
+	return nil
+}
+
+func overlaps(a, b engo.AABB) bool {
+	return a.Min.X < b.Max.X && a.Max.X > b.Min.X && a.Min.Y < b.Max.Y && a.Max.Y > b.Min.Y
+}
+
+// HandleSpacialRequestMessage sends the creature straight to the nearest tile
+// it's looking for, or a random one if it doesn't care about the distance
+func (self *World) HandleSpacialRequestMessage(m engo.Message) {
+	msg, ok := m.(messages.SpacialRequestMessage)
+	if !ok {
+		return
+	}
+	var result []*data.Tile
+	for _, t := range self.Tiles {
+		if overlaps(msg.Aabb, t.AABB()) && (msg.Filter == nil || msg.Filter(t)) {
+			result = append(result, t)
+		}
+	}
+	c := self.Creature
+	if len(result) == 0 || c.MovementTarget != nil {
+		return
+	}
+	if msg.From == nil {
+		c.MovementTarget = result[self.rand.Intn(len(result))]
+		return
+	}
+	from := *msg.From
+	sort.SliceStable(result, func(i, j int) bool {
+		return from.PointDistance(result[i].SpaceComponent.Position) < from.PointDistance(result[j].SpaceComponent.Position)
+	})
+	c.MovementTarget = result[0]
+}
+
+func (self *World) HandleTileRemoveMessage(m engo.Message) {
+	msg, ok := m.(messages.TileRemoveMessage)
+	if !ok {
+		return
+	}
+	for i, t := range self.Tiles {
+		if t.BasicEntity.ID() == msg.Entity.ID() {
+			self.Tiles = append(self.Tiles[:i], self.Tiles[i+1:]...)
+			return
+		}
+	}
+}
+
+// HandleTileReplaceMessage swaps the object of a tile, e.g. when the creature
+// dies and leaves its corpse
+func (self *World) HandleTileReplaceMessage(m engo.Message) {
+	msg, ok := m.(messages.TileReplaceMessage)
+	if !ok {
+		return
+	}
+	tile := self.Creature.Tile
+	if tile.BasicEntity.ID() != msg.Entity.ID() {
+		for _, t := range self.Tiles {
+			if t.BasicEntity.ID() == msg.Entity.ID() {
+				tile = t
+			}
+		}
+	}
+	tile.ObjectID = msg.ObjectID
+	self.prepare(tile)
+}
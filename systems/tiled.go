@@ -0,0 +1,110 @@
+package systems
+
+import (
+	"fmt"
+	"github.com/EngoEngine/ecs"
+	"github.com/EngoEngine/engo"
+	"github.com/EngoEngine/engo/common"
+	"gogame/messages"
+	"gogame/tiled"
+	"gogame/util"
+	"log"
+	"time"
+)
+
+// ImportMap spawns the ground, objects, plants and creatures of a Tiled map
+func (self *WorldTilesSystem) ImportMap(m *tiled.Map) {
+	var plantSystem *PlantSpawningSystem
+	var creatureSystem *CreatureSpawningSystem
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *PlantSpawningSystem:
+			plantSystem = sys
+		case *CreatureSpawningSystem:
+			creatureSystem = sys
+		}
+	}
+
+	size := util.WorldSize{Width: m.Width, Height: m.Height}
+	for _, t := range m.Tiles {
+		size.Fit(t.X, t.Y)
+	}
+	self.SetSize(size)
+
+	for _, t := range m.Tiles {
+		collisionC := &common.CollisionComponent{Main: 0, Group: 0}
+		if t.Layer != tiled.GroundLayer {
+			collisionC = &common.CollisionComponent{Main: 0, Group: 1}
+		}
+		self.Add(NewTile(t.ObjectID, util.ToPoint(t.X, t.Y), t.Layer, collisionC))
+	}
+	for _, e := range m.Entities {
+		position := util.ToPoint(e.X, e.Y)
+		switch e.Kind {
+		case tiled.PlantKind:
+			if plantSystem == nil {
+				continue
+			}
+			plant := NewPlant(e.ID, position)
+			if err := e.Apply(plant); err != nil {
+				log.Printf("[WorldTilesSystem] plant at %v: %s", position, err)
+			}
+			if _, ok := e.Properties["nutrients"]; !ok {
+				// Made of the nutrients of the growth set on the map
+				plant.Nutrients = plant.Growth * plant.NutrientUptake
+			}
+			plantSystem.Add(plant)
+		case tiled.CreatureKind:
+			if creatureSystem == nil {
+				continue
+			}
+			creature := NewCreature(e.ID, position)
+			if err := e.Apply(creature); err != nil {
+				log.Printf("[WorldTilesSystem] creature at %v: %s", position, err)
+			}
+			creatureSystem.Add(creature)
+		}
+	}
+	self.Autotile()
+}
+
+// ImportMapFile loads a Tiled map and spawns its contents, reporting any
+// problems with the file in the HUD
+func (self *WorldTilesSystem) ImportMapFile(path string) error {
+	m, err := tiled.Load(path)
+	if err != nil {
+		log.Printf("[WorldTilesSystem] could not import '%s': %s", path, err)
+		engo.Mailbox.Dispatch(messages.HUDTextUpdateMessage{
+			Name:      "EventMessage",
+			HideAfter: 5 * time.Second,
+			GetText: func() string {
+				return fmt.Sprintf("Could not import %s: %s", path, err)
+			},
+		})
+		return err
+	}
+	self.ImportMap(m)
+	return nil
+}
+
+// ExportMap collects the world into a map which can be saved for Tiled
+func ExportMap(world *ecs.World) *tiled.Map {
+	m := &tiled.Map{SeenEntityIDs: make(map[uint64]struct{})}
+	// Creatures and plants first, so they are exported as entities rather than tiles
+	for _, system := range world.Systems() {
+		if sys, ok := system.(*CreatureSpawningSystem); ok {
+			sys.UpdateMap(m)
+		}
+	}
+	for _, system := range world.Systems() {
+		if sys, ok := system.(*PlantSpawningSystem); ok {
+			sys.UpdateMap(m)
+		}
+	}
+	for _, system := range world.Systems() {
+		if sys, ok := system.(*WorldTilesSystem); ok {
+			sys.UpdateMap(m)
+		}
+	}
+	return m
+}
@@ -33,20 +33,38 @@ func (self *TimeSystem) New(w *ecs.World) {
 
 func (*TimeSystem) Add() {}
 
+// Speed is the seconds of game time passing per second, 0 while paused
+func (self *TimeSystem) Speed() float32 {
+	return self.speed
+}
+
 func (self *TimeSystem) Update(dt float32) {
 	if self.speed == 0 {
 		// Paused
 		return
 	}
-	if self.dtFullSeconds*self.speed >= 1 {
-		self.dtFullSeconds = 0
+	// A second passes for each whole second of game time accumulated, the
+	// rest is kept for the next update
+	for self.dtFullSeconds >= 1 {
+		self.dtFullSeconds--
 		self.Time.AddSecond()
 		engo.Mailbox.Dispatch(messages.TimeSecondPassedMessage{
 			Time: self.Time,
-			Dt:   self.dtFullSeconds,
+			Dt:   1,
 		})
+		if self.Time.IsSunrise() {
+			engo.Mailbox.Dispatch(messages.TimeSunriseMessage{
+				Time: self.Time,
+				Dt:   1,
+			})
+		} else if self.Time.IsSunset() {
+			engo.Mailbox.Dispatch(messages.TimeSunsetMessage{
+				Time: self.Time,
+				Dt:   1,
+			})
+		}
 	}
-	self.dtFullSeconds += dt
+	self.dtFullSeconds += dt * self.speed
 	// TODO might be the good place to implement speed of in-game time
 	// TODO and the PauseSystem
 }
@@ -12,6 +12,8 @@ import (
 	"gogame/messages"
 	"gogame/save"
 	"gogame/shaders"
+	"gogame/terrain"
+	"gogame/tiled"
 	"gogame/util"
 	"log"
 	"math/rand"
@@ -20,6 +22,17 @@ import (
 type WorldTilesSystem struct {
 	world *ecs.World
 	tiles []*data.Tile // TODO entities
+
+	// Ground tiles by their grid position, and the terrain transitions drawn over them
+	ground      map[gridIndex]*data.Tile
+	transitions map[gridIndex]*Transition
+	autotiler   *terrain.Autotiler
+	// Impassable objects by their grid position
+	blocked map[gridIndex]int
+
+	seed int64
+	rand *rand.Rand
+	size util.WorldSize
 }
 
 func NewTile(objectID int, position *engo.Point, layer float32, collisionComponent *common.CollisionComponent) *data.Tile {
@@ -69,6 +82,10 @@ func (self *WorldTilesSystem) Add(tile *data.Tile) {
 		}
 	}
 	self.tiles = append(self.tiles, tile)
+	if tile.Layer == 0 {
+		self.ground[tileGridIndex(tile)] = tile
+	}
+	self.block(tile, 1)
 
 	// Add the tile to the various systems
 	for _, system := range self.world.Systems() {
@@ -84,65 +101,135 @@ func (self *WorldTilesSystem) Add(tile *data.Tile) {
 		case *controls.ControlsSystem:
 			sys.Add(tile.BasicEntity, tile.MouseComponent, tile.SpaceComponent, tile.RenderComponent)
 		case *SpacialSystem:
-			sys.Add(tile)
+			sys.Add(tile.BasicEntity, tile)
+		case *SoilSystem:
+			if tile.Layer == 0 {
+				sys.Add(tile)
+			}
 		}
 	}
 }
 
 func (self *WorldTilesSystem) ReplaceObject(tile *data.Tile, objectID int) {
+	self.block(tile, -1)
 	tile.ObjectID = objectID
 	tile.Object = assets.GetObjectById(objectID)
-	if tile.Resource == nil && tile.Object.ResourceID != 0 {
+	self.block(tile, 1)
+	// The resource changes with the object, e.g. a flowering plant is no longer
+	// the food a young one was
+	tile.Resource = nil
+	if tile.Object.ResourceID != 0 {
 		tile.Resource = assets.GetResourceByID(tile.Object.ResourceID)
 	}
 	tile.RenderComponent.Drawable = tile.Object.Spritesheet.Cell(tile.Object.SpriteID)
-	tile.AccessibleResource = &data.AccessibleResource{tile.Object.ResourceID, tile.Object.Amount}
+	// A plant keeps what it has grown of the same resource as it matures
+	amount := tile.Object.Amount
+	if tile.AccessibleResource != nil && tile.AccessibleResource.ResourceID == tile.Object.ResourceID {
+		amount = tile.AccessibleResource.Amount
+	}
+	tile.AccessibleResource = &data.AccessibleResource{tile.Object.ResourceID, amount}
 	// TODO update animations if any
+	for _, system := range self.world.Systems() {
+		switch sys := system.(type) {
+		case *SpacialSystem:
+			sys.Move(*tile.BasicEntity)
+		case *SoilSystem:
+			if tile.Layer == 0 {
+				sys.Replace(tile)
+			}
+		}
+	}
+	if tile.Layer == 0 {
+		self.AutotileAround(tile)
+	}
 }
 
 func (self *WorldTilesSystem) New(world *ecs.World) {
 	self.world = world
 	self.tiles = make([]*data.Tile, 0)
+	self.ground = make(map[gridIndex]*data.Tile)
+	self.transitions = make(map[gridIndex]*Transition)
+	self.blocked = make(map[gridIndex]int)
+	if assets.Tileset != nil {
+		self.autotiler = terrain.NewAutotiler(assets.Tileset, terrain.Priority)
+	}
+	self.SetSeed(0)
+	self.size = util.WorldSize{Width: config.WorldWidth, Height: config.WorldHeight}
 
 	engo.Mailbox.Listen(messages.InteractionMessageType, self.HandleInteractMessage)
 	engo.Mailbox.Listen(messages.ControlMessageType, self.HandleControlMessage)
 	engo.Mailbox.Listen(messages.TileRemoveMessageType, self.HandleTileRemoveMessage)
 	engo.Mailbox.Listen(messages.TileReplaceMessageType, self.HandleTileReplaceMessage)
+	engo.Mailbox.Listen(messages.WorldSeedMessageType, self.HandleWorldSeedMessage)
+	engo.Mailbox.Listen(messages.WorldSizeMessageType, self.HandleWorldSizeMessage)
+}
+
+// SetSeed resets the world generator, the same seed always generates the same world
+func (self *WorldTilesSystem) SetSeed(seed int64) {
+	self.seed = seed
+	self.rand = util.NewRand(seed, "world")
+}
+
+func (self *WorldTilesSystem) Seed() int64 {
+	return self.seed
+}
+
+// Size returns the size of the world, in tiles
+func (self *WorldTilesSystem) Size() util.WorldSize {
+	return self.size
+}
+
+// SetSize resizes the world, and everything bound to its size
+func (self *WorldTilesSystem) SetSize(size util.WorldSize) {
+	engo.Mailbox.Dispatch(messages.WorldSizeMessage{Size: size})
 }
 
 func (self *WorldTilesSystem) Generate() {
-	mapSizeX, mapSizeY := 50, 50
-	groundID := 6 // FIXME grassland, default ground
+	self.SetSize(self.size)
+	mapSizeX, mapSizeY := self.size.Width, self.size.Height
+	terrainMap := terrain.Generate(self.rand, mapSizeX, mapSizeY)
 	// ground doesn't collide with anything
 	collisionC := &common.CollisionComponent{Main: 0, Group: 0}
 	for i := 0; i < mapSizeX; i++ {
 		for j := 0; j < mapSizeY; j++ {
+			biome := terrainMap.Biomes[i][j]
 			position := util.ToPoint(i, j)
-			tile := NewTile(groundID, position, 0, collisionC)
+			tile := NewTile(assets.GetObjectByTerrain(biome.Terrain).ID, position, 0, collisionC)
 			self.Add(tile)
 
+			if biome.Rocky {
+				if self.rand.Intn(6) == 0 {
+					boulder := NewTile(8, position, 4, &common.CollisionComponent{Main: 0, Group: 1})
+					self.Add(boulder)
+				}
+				continue
+			}
+			if !biome.Fertile {
+				continue
+			}
+
 			// Add a random vegetation
-			if rand.Int()%5 == 0 {
+			if self.rand.Intn(5) == 0 {
 				engo.Mailbox.Dispatch(messages.NewPlantMessage{
 					Point:   position,
 					PlantID: 1,
 				})
-			} else if rand.Int()%6 == 0 {
+			} else if self.rand.Intn(6) == 0 {
 				engo.Mailbox.Dispatch(messages.NewPlantMessage{
 					Point:   position,
 					PlantID: 2,
 				})
-			} else if rand.Int()%7 == 0 {
+			} else if self.rand.Intn(7) == 0 {
 				engo.Mailbox.Dispatch(messages.NewPlantMessage{
 					Point:   position,
 					PlantID: 3,
 				})
-			} else if rand.Int()%8 == 0 {
+			} else if self.rand.Intn(8) == 0 {
 				engo.Mailbox.Dispatch(messages.NewPlantMessage{
 					Point:   position,
 					PlantID: 4,
 				})
-			} else if rand.Int()%9 == 0 {
+			} else if self.rand.Intn(9) == 0 {
 				engo.Mailbox.Dispatch(messages.NewPlantMessage{
 					Point:   position,
 					PlantID: 5,
@@ -151,14 +238,12 @@ func (self *WorldTilesSystem) Generate() {
 
 		}
 	}
-	common.CameraBounds = engo.AABB{
-		Min: engo.Point{0, 0},
-		Max: engo.Point{
-			float32(mapSizeX * config.SpriteWidth),
-			float32(mapSizeY * config.SpriteHeight),
-		},
-	}
-	common.MaxZoom = 1.5
+	self.Autotile()
+}
+
+// Tiles returns all tiles of the world, on all layers
+func (self *WorldTilesSystem) Tiles() []*data.Tile {
+	return self.tiles
 }
 
 func (self *WorldTilesSystem) GetEntityByID(basicEntityID uint64) *data.Tile {
@@ -215,6 +300,8 @@ func (self *WorldTilesSystem) HandleControlMessage(m engo.Message) {
 	} else if msg.Action == "WorldGenerate" {
 		// TODO the game should be paused first
 		self.Generate()
+	} else if msg.Action == "WorldImport" {
+		self.ImportMapFile(msg.Data)
 	}
 }
 
@@ -239,6 +326,24 @@ func (self *WorldTilesSystem) HandleTileReplaceMessage(m engo.Message) {
 	self.ReplaceObject(tile, msg.ObjectID)
 }
 
+func (self *WorldTilesSystem) HandleWorldSeedMessage(m engo.Message) {
+	msg, ok := m.(messages.WorldSeedMessage)
+	if !ok {
+		return
+	}
+	self.SetSeed(msg.Seed)
+}
+
+func (self *WorldTilesSystem) HandleWorldSizeMessage(m engo.Message) {
+	msg, ok := m.(messages.WorldSizeMessage)
+	if !ok {
+		return
+	}
+	self.size = msg.Size
+	common.CameraBounds = self.size.Bounds()
+	common.MaxZoom = 1.5
+}
+
 func (self *WorldTilesSystem) Update(dt float32) {}
 
 func (self *WorldTilesSystem) Remove(e ecs.BasicEntity) {
@@ -249,11 +354,15 @@ func (self *WorldTilesSystem) Remove(e ecs.BasicEntity) {
 		}
 	}
 	if delete >= 0 {
+		self.removeGround(self.tiles[delete])
+		self.block(self.tiles[delete], -1)
 		self.tiles = append(self.tiles[:delete], self.tiles[delete+1:]...)
 	}
 }
 
 func (self *WorldTilesSystem) UpdateSave(saveFile *save.SaveFile) {
+	saveFile.Seed = self.seed
+	saveFile.Size = self.size
 	for _, t := range self.tiles {
 		entityID := t.BasicEntity.ID()
 		if _, ok := saveFile.SeenEntityIDs[entityID]; !ok {
@@ -263,9 +372,32 @@ func (self *WorldTilesSystem) UpdateSave(saveFile *save.SaveFile) {
 	}
 }
 
+// UpdateMap adds the ground and the objects to an exported map
+func (self *WorldTilesSystem) UpdateMap(m *tiled.Map) {
+	m.Width, m.Height = self.size.Width, self.size.Height
+	for _, t := range self.tiles {
+		entityID := t.BasicEntity.ID()
+		if _, ok := m.SeenEntityIDs[entityID]; ok {
+			continue
+		}
+		x, y := util.ToGridIndex(t.SpaceComponent.Position.X, t.SpaceComponent.Position.Y)
+		m.Tiles = append(m.Tiles, tiled.Tile{X: x, Y: y, Layer: t.Layer, ObjectID: t.ObjectID})
+		m.SeenEntityIDs[entityID] = struct{}{}
+	}
+}
+
 func (self *WorldTilesSystem) LoadSave(saveFile *save.SaveFile) {
 	log.Printf("[WorldTilesSystem] Tiles in the save file: %d\n", len(saveFile.Tiles))
+	size := saveFile.Size
+	if size.Width == 0 || size.Height == 0 {
+		// Saved before the size was, derive it from the tiles
+		for _, t := range saveFile.Tiles {
+			size.Fit(util.ToGridIndex(t.SpaceComponent.Position.X, t.SpaceComponent.Position.Y))
+		}
+	}
+	self.SetSize(size)
 	for _, t := range saveFile.Tiles {
 		self.Add(t)
 	}
+	self.Autotile()
 }
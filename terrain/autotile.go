@@ -0,0 +1,88 @@
+package terrain
+
+// Priority lists the terrains that take part in autotiling, from the bottom up:
+// at a corner shared by several terrains the one listed last is drawn.
+var Priority = []string{WaterDeep, Water, Sand, DirtBrown, Grass, GrassDark, RockGray, Snow}
+
+// NoOverlay is returned by Autotiler.Sprites for tiles that don't need one
+const NoOverlay = -1
+
+// Autotiler picks transition sprites for tiles from the terrains of their
+// neighbours
+type Autotiler struct {
+	Tileset  *Tileset
+	priority map[string]int
+}
+
+func NewAutotiler(tileset *Tileset, priority []string) *Autotiler {
+	a := &Autotiler{Tileset: tileset, priority: make(map[string]int)}
+	for i, t := range priority {
+		a.priority[t] = i
+	}
+	return a
+}
+
+// Participates tells whether the terrain is autotiled at all
+func (self *Autotiler) Participates(terrain string) bool {
+	_, ok := self.priority[terrain]
+	return ok
+}
+
+// VertexTerrain picks the terrain drawn at a corner shared by tiles of the given
+// terrains. Terrains which don't take part in autotiling are ignored.
+func (self *Autotiler) VertexTerrain(terrains ...string) string {
+	result, best := "", -1
+	for _, t := range terrains {
+		p, ok := self.priority[t]
+		if ok && p > best {
+			result, best = t, p
+		}
+	}
+	return result
+}
+
+// Sprites returns the sprite of a tile of the `own` terrain, given the terrains
+// at its corners, and an overlay sprite to be drawn over it, or NoOverlay.
+// Where the tileset has no tile mixing both terrains, the lower terrain is
+// drawn as a whole and the higher one as a partially transparent overlay.
+func (self *Autotiler) Sprites(own string, corners Corners) (int, int) {
+	full, _ := self.Tileset.FullTile(own)
+
+	lowest, highest := own, own
+	ownPresent := false
+	for _, c := range corners {
+		if c == own {
+			ownPresent = true
+		}
+		if self.priority[c] < self.priority[lowest] {
+			lowest = c
+		}
+		if self.priority[c] > self.priority[highest] {
+			highest = c
+		}
+	}
+	// A tile must never look like another terrain as a whole, even though
+	// e.g. a single tile of water amidst grass has grass at all of its corners
+	if !ownPresent || lowest == highest {
+		return full, NoOverlay
+	}
+
+	if id, ok := self.Tileset.TileFor(corners); ok {
+		return id, NoOverlay
+	}
+
+	base, ok := self.Tileset.FullTile(lowest)
+	if !ok {
+		return full, NoOverlay
+	}
+	var overlay Corners
+	for i, c := range corners {
+		if c == highest {
+			overlay[i] = highest
+		}
+	}
+	if id, ok := self.Tileset.TileFor(overlay); ok {
+		return base, id
+	}
+	return full, NoOverlay
+}
@@ -0,0 +1,65 @@
+package terrain
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Noise is a 2D value noise: random values on the integer lattice, smoothly
+// interpolated in between
+type Noise struct {
+	perm   [512]int
+	values [256]float64
+}
+
+func NewNoise(r *rand.Rand) *Noise {
+	n := &Noise{}
+	for i, p := range r.Perm(256) {
+		n.perm[i] = p
+		n.perm[i+256] = p
+	}
+	for i := range n.values {
+		n.values[i] = r.Float64()
+	}
+	return n
+}
+
+func (self *Noise) lattice(x, y int) float64 {
+	return self.values[self.perm[self.perm[x&255]+y&255]]
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// At returns the noise value in [0, 1) at the given point
+func (self *Noise) At(x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	ix, iy := int(x0), int(y0)
+	tx, ty := smoothstep(x-x0), smoothstep(y-y0)
+
+	top := lerp(self.lattice(ix, iy), self.lattice(ix+1, iy), tx)
+	bottom := lerp(self.lattice(ix, iy+1), self.lattice(ix+1, iy+1), tx)
+	return lerp(top, bottom, ty)
+}
+
+// Fractal sums several octaves of the noise, each with double the frequency
+// and `persistence` times the amplitude of the previous one. The result is
+// in [0, 1).
+func (self *Noise) Fractal(x, y float64, octaves int, persistence float64) float64 {
+	var sum, norm float64
+	amplitude, frequency := 1.0, 1.0
+	for i := 0; i < octaves; i++ {
+		// Shift each octave so the lattice points don't line up
+		offset := float64(i) * 17.31
+		sum += self.At(x*frequency+offset, y*frequency+offset) * amplitude
+		norm += amplitude
+		amplitude *= persistence
+		frequency *= 2
+	}
+	return sum / norm
+}
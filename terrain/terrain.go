@@ -0,0 +1,110 @@
+package terrain
+
+import (
+	"math/rand"
+)
+
+// Terrain types, named as in assets/tilemap/terrain-v7.tsx
+const (
+	WaterDeep = "Water_Deep"
+	Water     = "Water"
+	Sand      = "Sand"
+	Grass     = "Grass"
+	GrassDark = "Grass_Dark"
+	DirtBrown = "Dirt_Brown"
+	RockGray  = "Rock_Gray"
+	Snow      = "Snow_1"
+)
+
+// Biome maps a range of elevation and moisture to a terrain type
+type Biome struct {
+	MaxElevation float64
+	MaxMoisture  float64
+	Terrain      string
+	Fertile      bool // Plants can grow here
+	Rocky        bool // Boulders are scattered here
+}
+
+// Biomes are checked in order, the first one that fits both the elevation and
+// the moisture of a tile wins
+var Biomes = []Biome{
+	{MaxElevation: 0.25, MaxMoisture: 1, Terrain: WaterDeep},
+	{MaxElevation: 0.35, MaxMoisture: 1, Terrain: Water},
+	{MaxElevation: 0.40, MaxMoisture: 1, Terrain: Sand},
+	{MaxElevation: 0.70, MaxMoisture: 0.30, Terrain: DirtBrown, Fertile: true},
+	{MaxElevation: 0.70, MaxMoisture: 0.65, Terrain: Grass, Fertile: true},
+	{MaxElevation: 0.70, MaxMoisture: 1, Terrain: GrassDark, Fertile: true},
+	{MaxElevation: 0.88, MaxMoisture: 1, Terrain: RockGray, Rocky: true},
+	{MaxElevation: 1, MaxMoisture: 1, Terrain: Snow},
+}
+
+// Scale of the features, in tiles
+const (
+	elevationScale = 24.0
+	moistureScale  = 16.0
+	octaves        = 4
+	persistence    = 0.5
+)
+
+// Map is a generated terrain, indexed [x][y]
+type Map struct {
+	Width, Height int
+	Elevation     [][]float64
+	Moisture      [][]float64
+	Biomes        [][]*Biome
+}
+
+// Generate produces elevation and moisture fields of the given size and maps
+// them to biomes. The same random number generator state always produces the
+// same map.
+func Generate(r *rand.Rand, width, height int) *Map {
+	m := &Map{Width: width, Height: height}
+	m.Elevation = field(NewNoise(r), width, height, elevationScale)
+	m.Moisture = field(NewNoise(r), width, height, moistureScale)
+	m.Biomes = make([][]*Biome, width)
+	for i := 0; i < width; i++ {
+		m.Biomes[i] = make([]*Biome, height)
+		for j := 0; j < height; j++ {
+			m.Biomes[i][j] = GetBiome(m.Elevation[i][j], m.Moisture[i][j])
+		}
+	}
+	return m
+}
+
+func GetBiome(elevation, moisture float64) *Biome {
+	for i := range Biomes {
+		if elevation <= Biomes[i].MaxElevation && moisture <= Biomes[i].MaxMoisture {
+			return &Biomes[i]
+		}
+	}
+	return &Biomes[len(Biomes)-1]
+}
+
+// field samples fractal noise over the map and stretches the values to
+// [0, 1], so that the biome thresholds hold regardless of the map size
+func field(noise *Noise, width, height int, scale float64) [][]float64 {
+	values := make([][]float64, width)
+	min, max := 1.0, 0.0
+	for i := 0; i < width; i++ {
+		values[i] = make([]float64, height)
+		for j := 0; j < height; j++ {
+			v := noise.Fractal(float64(i)/scale, float64(j)/scale, octaves, persistence)
+			values[i][j] = v
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+	}
+	if max <= min {
+		return values
+	}
+	for i := range values {
+		for j := range values[i] {
+			values[i][j] = (values[i][j] - min) / (max - min)
+		}
+	}
+	return values
+}
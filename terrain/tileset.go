@@ -0,0 +1,142 @@
+package terrain
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Corners are the terrain names at the top-left, top-right, bottom-left and
+// bottom-right corners of a tile, in the order used by Tiled. An empty
+// string is a corner without any terrain, i.e. a transparent one.
+type Corners [4]string
+
+type TerrainType struct {
+	Name string `xml:"name,attr"`
+	Tile int    `xml:"tile,attr"`
+}
+
+type tsxTile struct {
+	ID      int    `xml:"id,attr"`
+	Terrain string `xml:"terrain,attr"`
+}
+
+type tsxImage struct {
+	Source string `xml:"source,attr"`
+}
+
+type tsxTileset struct {
+	Name       string        `xml:"name,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	TileCount  int           `xml:"tilecount,attr"`
+	Columns    int           `xml:"columns,attr"`
+	Image      tsxImage      `xml:"image"`
+	Terrains   []TerrainType `xml:"terraintypes>terrain"`
+	Tiles      []tsxTile     `xml:"tile"`
+}
+
+// Tileset is the terrain metadata of a Tiled tileset (.tsx)
+type Tileset struct {
+	Name       string
+	TileWidth  int
+	TileHeight int
+	TileCount  int
+	Columns    int
+	Image      string // Source of the image, relative to the tileset
+	Terrains   []TerrainType
+
+	terrainIndex map[string]int
+	// Tile IDs by the terrain indexes of their corners, -1 for no terrain
+	tiles   map[[4]int]int
+	corners map[int]Corners
+}
+
+func LoadTileset(r io.Reader) (*Tileset, error) {
+	tsx := &tsxTileset{}
+	if err := xml.NewDecoder(r).Decode(tsx); err != nil {
+		return nil, err
+	}
+	tileset := &Tileset{
+		Name:         tsx.Name,
+		TileWidth:    tsx.TileWidth,
+		TileHeight:   tsx.TileHeight,
+		TileCount:    tsx.TileCount,
+		Columns:      tsx.Columns,
+		Image:        tsx.Image.Source,
+		Terrains:     tsx.Terrains,
+		terrainIndex: make(map[string]int),
+		tiles:        make(map[[4]int]int),
+		corners:      make(map[int]Corners),
+	}
+	for i, t := range tsx.Terrains {
+		tileset.terrainIndex[t.Name] = i
+	}
+	for _, t := range tsx.Tiles {
+		if t.Terrain == "" {
+			continue
+		}
+		parts := strings.Split(t.Terrain, ",")
+		if len(parts) != 4 {
+			continue
+		}
+		var key [4]int
+		var corners Corners
+		for i, p := range parts {
+			key[i] = -1
+			if p == "" {
+				continue
+			}
+			index, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, err
+			}
+			if index < 0 || index >= len(tileset.Terrains) {
+				return nil, fmt.Errorf("tile %d refers to an unknown terrain %d", t.ID, index)
+			}
+			key[i] = index
+			corners[i] = tileset.Terrains[index].Name
+		}
+		tileset.corners[t.ID] = corners
+		// Several tiles may share the same corners, keep the first one
+		if _, ok := tileset.tiles[key]; !ok {
+			tileset.tiles[key] = t.ID
+		}
+	}
+	return tileset, nil
+}
+
+// TileFor returns the ID of a tile with exactly the given corners
+func (self *Tileset) TileFor(corners Corners) (int, bool) {
+	var key [4]int
+	for i, c := range corners {
+		key[i] = -1
+		if c == "" {
+			continue
+		}
+		index, ok := self.terrainIndex[c]
+		if !ok {
+			return 0, false
+		}
+		key[i] = index
+	}
+	id, ok := self.tiles[key]
+	return id, ok
+}
+
+// FullTile returns the ID of the tile representing the terrain as a whole
+func (self *Tileset) FullTile(terrain string) (int, bool) {
+	index, ok := self.terrainIndex[terrain]
+	if !ok {
+		return 0, false
+	}
+	return self.Terrains[index].Tile, true
+}
+
+// CornersOf returns the terrains at the corners of the tile
+func (self *Tileset) CornersOf(tileID int) (Corners, bool) {
+	corners, ok := self.corners[tileID]
+	return corners, ok
+}
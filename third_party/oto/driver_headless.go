@@ -0,0 +1,13 @@
+// +build linux freebsd openbsd
+// +build !js
+// +build !android
+// +build !ios
+// +build headless
+
+package oto
+
+// Headless builds play no sound, so that they don't need the audio libraries
+// of the system, e.g. ALSA
+func newDriver(sampleRate, channelNum, bitDepthInBytes, bufferSizeInBytes int) (tryWriteCloser, error) {
+	return newDummyDriver(sampleRate, channelNum, bitDepthInBytes), nil
+}
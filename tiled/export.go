@@ -0,0 +1,269 @@
+package tiled
+
+import (
+	"encoding/xml"
+	"fmt"
+	"gogame/assets"
+	"gogame/config"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:"value,attr"`
+}
+
+type tmxTileset struct {
+	FirstGID int    `xml:"firstgid,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+type tmxData struct {
+	Encoding string `xml:"encoding,attr"`
+	Tiles    string `xml:",innerxml"`
+}
+
+type tmxLayer struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+	Data       tmxData       `xml:"data"`
+}
+
+type tmxObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr,omitempty"`
+	Type       string        `xml:"type,attr,omitempty"`
+	GID        int           `xml:"gid,attr,omitempty"`
+	X          int           `xml:"x,attr"`
+	Y          int           `xml:"y,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxObjectGroup struct {
+	ID      int         `xml:"id,attr"`
+	Name    string      `xml:"name,attr"`
+	Objects []tmxObject `xml:"object"`
+}
+
+type tmxMap struct {
+	XMLName      xml.Name       `xml:"map"`
+	Version      string         `xml:"version,attr"`
+	Orientation  string         `xml:"orientation,attr"`
+	RenderOrder  string         `xml:"renderorder,attr"`
+	Width        int            `xml:"width,attr"`
+	Height       int            `xml:"height,attr"`
+	TileWidth    int            `xml:"tilewidth,attr"`
+	TileHeight   int            `xml:"tileheight,attr"`
+	Infinite     int            `xml:"infinite,attr"`
+	NextLayerID  int            `xml:"nextlayerid,attr"`
+	NextObjectID int            `xml:"nextobjectid,attr"`
+	Tileset      tmxTileset     `xml:"tileset"`
+	Layers       []tmxLayer     `xml:"layer"`
+	ObjectGroup  tmxObjectGroup `xml:"objectgroup"`
+}
+
+// Save writes the map to a Tiled map (.tmx) drawn with the game's tileset,
+// with a tile layer per layer of the game and the creatures and plants as
+// objects. Tiles drawn from other spritesheets are left out.
+func Save(path string, m *Map) error {
+	source, err := filepath.Abs(assets.TilesetPath)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(dir, source); err == nil {
+		source = rel
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return encode(f, m, filepath.ToSlash(source))
+}
+
+func encode(w io.Writer, m *Map, tilesetSource string) error {
+	if assets.Tileset == nil {
+		return fmt.Errorf("the tileset is not loaded")
+	}
+	spritesheet, ok := assets.GetSpritesheetByFilename(assets.Tileset.Image)
+	if !ok {
+		return fmt.Errorf("no spritesheet is loaded from '%s'", assets.Tileset.Image)
+	}
+	// GID of the tile drawing the object, 0 if the tileset doesn't have it
+	gid := func(objectID int) int {
+		object := assets.GetObjectById(objectID)
+		if object.SpritesheetID != spritesheet.ID {
+			return 0
+		}
+		return object.SpriteID + 1
+	}
+
+	// Grow the map to fit everything on it
+	width, height := m.Width, m.Height
+	fit := func(x, y int) {
+		if x >= width {
+			width = x + 1
+		}
+		if y >= height {
+			height = y + 1
+		}
+	}
+	for _, t := range m.Tiles {
+		fit(t.X, t.Y)
+	}
+	for _, e := range m.Entities {
+		fit(e.X, e.Y)
+	}
+
+	raw := &tmxMap{
+		Version:     "1.4",
+		Orientation: "orthogonal",
+		RenderOrder: "right-down",
+		Width:       width,
+		Height:      height,
+		TileWidth:   config.SpriteWidth,
+		TileHeight:  config.SpriteHeight,
+		Tileset:     tmxTileset{FirstGID: 1, Source: tilesetSource},
+	}
+
+	// Tile layers, from the bottom up
+	grids := make(map[float32][]int)
+	skipped := 0
+	for _, t := range m.Tiles {
+		if t.X < 0 || t.Y < 0 {
+			skipped++
+			continue
+		}
+		g := gid(t.ObjectID)
+		if g == 0 {
+			skipped++
+			continue
+		}
+		if _, ok := grids[t.Layer]; !ok {
+			grids[t.Layer] = make([]int, width*height)
+		}
+		grids[t.Layer][t.Y*width+t.X] = g
+	}
+	if skipped > 0 {
+		log.Printf("[tiled] %d tiles cannot be drawn with the tileset '%s'", skipped, assets.Tileset.Name)
+	}
+	layers := make([]float32, 0, len(grids))
+	for layer := range grids {
+		layers = append(layers, layer)
+	}
+	sort.Slice(layers, func(i, j int) bool { return layers[i] < layers[j] })
+	for i, layer := range layers {
+		raw.Layers = append(raw.Layers, tmxLayer{
+			ID:         i + 1,
+			Name:       layerName(layer),
+			Width:      width,
+			Height:     height,
+			Properties: []tmxProperty{property("layer", float64(layer))},
+			Data:       tmxData{Encoding: "csv", Tiles: csv(grids[layer], width)},
+		})
+	}
+
+	// Creatures and plants
+	raw.ObjectGroup = tmxObjectGroup{ID: len(layers) + 1, Name: "entities"}
+	for i, e := range m.Entities {
+		o := tmxObject{
+			ID:     i + 1,
+			Type:   e.Kind,
+			X:      e.X * config.SpriteWidth,
+			Y:      e.Y * config.SpriteHeight,
+			Width:  config.SpriteWidth,
+			Height: config.SpriteHeight,
+		}
+		if e.ObjectID != 0 {
+			if o.GID = gid(e.ObjectID); o.GID != 0 {
+				// Tile objects are aligned to their bottom-left corner
+				o.Y += config.SpriteHeight
+			}
+		}
+		if name, ok := e.Properties["name"].(string); ok {
+			o.Name = name
+		}
+		o.Properties = append(o.Properties, property(e.Kind+"_id", e.ID))
+		keys := make([]string, 0, len(e.Properties))
+		for k := range e.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o.Properties = append(o.Properties, property(k, e.Properties[k]))
+		}
+		raw.ObjectGroup.Objects = append(raw.ObjectGroup.Objects, o)
+	}
+	raw.NextLayerID = len(layers) + 2
+	raw.NextObjectID = len(m.Entities) + 1
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", " ")
+	if err := enc.Encode(raw); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// layerName is the inverse of gameLayer
+func layerName(layer float32) string {
+	switch layer {
+	case GroundLayer:
+		return "ground"
+	case PlantLayer:
+		return "plants"
+	case ObjectLayer:
+		return "objects"
+	}
+	return fmt.Sprintf("layer %g", layer)
+}
+
+func csv(gids []int, width int) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for i, g := range gids {
+		b.WriteString(strconv.Itoa(g))
+		if i < len(gids)-1 {
+			b.WriteString(",")
+		}
+		if (i+1)%width == 0 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func property(name string, value interface{}) tmxProperty {
+	switch v := value.(type) {
+	case int:
+		return tmxProperty{Name: name, Type: "int", Value: strconv.Itoa(v)}
+	case float32:
+		return tmxProperty{Name: name, Type: "float", Value: strconv.FormatFloat(float64(v), 'f', -1, 32)}
+	case float64:
+		return tmxProperty{Name: name, Type: "float", Value: strconv.FormatFloat(v, 'f', -1, 64)}
+	case bool:
+		return tmxProperty{Name: name, Type: "bool", Value: strconv.FormatBool(v)}
+	}
+	return tmxProperty{Name: name, Value: fmt.Sprint(value)}
+}
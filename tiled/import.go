@@ -0,0 +1,321 @@
+// Package tiled translates maps made in the Tiled editor (.tmx) to and from
+// the game's objects, plants and creatures.
+package tiled
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Noofbiz/tmx"
+	"gogame/assets"
+	"gogame/life/plants"
+	"gogame/terrain"
+	"gogame/util"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Layers of the game, as used by the WorldTilesSystem
+const (
+	GroundLayer float32 = 0
+	PlantLayer  float32 = 2
+	ObjectLayer float32 = 4
+)
+
+const (
+	CreatureKind = "creature"
+	PlantKind    = "plant"
+)
+
+// Tile is a ground tile or a plain object, e.g. a boulder, at a grid position
+type Tile struct {
+	X, Y     int
+	Layer    float32
+	ObjectID int
+}
+
+// Entity is a creature or a plant at a grid position. Its properties override
+// the initial record of the species.
+type Entity struct {
+	Kind       string
+	ID         int
+	ObjectID   int // Object the entity is drawn as, 0 if unknown
+	X, Y       int
+	Properties map[string]interface{}
+}
+
+// Map is a Tiled map translated to the game's objects
+type Map struct {
+	Width, Height int
+	Tiles         []Tile
+	Entities      []Entity
+
+	// Entities already added to the map when exporting, creatures and plants
+	// are tiles of the world as well
+	SeenEntityIDs map[uint64]struct{}
+}
+
+// Properties of the map elements which have a special meaning
+var reservedProperties = []string{"kind", "layer", "creature_id", "plant_id"}
+
+// Apply sets the fields of a creature or a plant from the properties of the
+// entity, matched by their JSON names, e.g. "food" or "growth"
+func (self *Entity) Apply(v interface{}) error {
+	if len(self.Properties) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(self.Properties)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Load reads a Tiled map. Tile layers may be encoded as CSV or base64, either
+// uncompressed or compressed with zlib or gzip. External tilesets are looked
+// up relative to the map.
+func Load(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// The tmx package looks external tilesets up relative to this global
+	previous := tmx.TMXURL
+	tmx.TMXURL = path
+	defer func() { tmx.TMXURL = previous }()
+	raw, err := tmx.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	return translate(&raw)
+}
+
+type loader struct {
+	raw      *tmx.Map
+	tilesets []tmx.Tileset
+	result   *Map
+}
+
+func translate(raw *tmx.Map) (*Map, error) {
+	if raw.Orientation != "" && raw.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("%s maps are not supported", raw.Orientation)
+	}
+	if raw.TileWidth <= 0 || raw.TileHeight <= 0 {
+		return nil, fmt.Errorf("invalid tile size %dx%d", raw.TileWidth, raw.TileHeight)
+	}
+	l := &loader{
+		raw:      raw,
+		tilesets: append([]tmx.Tileset{}, raw.Tilesets...),
+		result:   &Map{Width: raw.Width, Height: raw.Height},
+	}
+	sort.Slice(l.tilesets, func(i, j int) bool {
+		return l.tilesets[i].FirstGID < l.tilesets[j].FirstGID
+	})
+
+	for i, layer := range raw.Layers {
+		if err := l.tileLayer(i, &layer); err != nil {
+			return nil, err
+		}
+	}
+	for _, group := range raw.ObjectGroups {
+		for _, o := range group.Objects {
+			if err := l.object(&o); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return l.result, nil
+}
+
+// gameLayer maps a layer of the map to the layers of the game: by its "layer"
+// property, its name, or else its order
+func gameLayer(index int, layer *tmx.Layer) (float32, error) {
+	for _, p := range layer.Properties {
+		if p.Name == "layer" {
+			value, err := strconv.ParseFloat(p.Value, 32)
+			return float32(value), err
+		}
+	}
+	switch strings.ToLower(layer.Name) {
+	case "ground", "terrain":
+		return GroundLayer, nil
+	case "plants", "vegetation":
+		return PlantLayer, nil
+	case "objects", "creatures":
+		return ObjectLayer, nil
+	}
+	switch index {
+	case 0:
+		return GroundLayer, nil
+	case 1:
+		return PlantLayer, nil
+	}
+	return ObjectLayer, nil
+}
+
+func (self *loader) tileLayer(index int, layer *tmx.Layer) error {
+	target, err := gameLayer(index, layer)
+	if err != nil {
+		return fmt.Errorf("layer '%s': %s", layer.Name, err)
+	}
+	for _, data := range layer.Data {
+		for i, t := range data.Tiles {
+			if err := self.tile(target, i%layer.Width, i/layer.Width, t.GID); err != nil {
+				return fmt.Errorf("layer '%s': %s", layer.Name, err)
+			}
+		}
+		// Infinite maps are made of chunks
+		for _, chunk := range data.Chunks {
+			for i, t := range chunk.Tiles {
+				x, y := chunk.X+i%chunk.Width, chunk.Y+i/chunk.Width
+				if err := self.tile(target, x, y, t.GID); err != nil {
+					return fmt.Errorf("layer '%s': %s", layer.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (self *loader) tile(layer float32, x, y int, gid uint32) error {
+	if gid == 0 {
+		// Empty
+		return nil
+	}
+	objectID, err := self.objectID(gid, layer == GroundLayer)
+	if err != nil {
+		return fmt.Errorf("tile at (%d, %d): %s", x, y, err)
+	}
+	if kind, id, ok := entityOf(objectID); ok && layer != GroundLayer {
+		self.result.Entities = append(self.result.Entities, Entity{Kind: kind, ID: id, ObjectID: objectID, X: x, Y: y})
+		return nil
+	}
+	self.result.Tiles = append(self.result.Tiles, Tile{X: x, Y: y, Layer: layer, ObjectID: objectID})
+	return nil
+}
+
+// entityOf finds a creature, or else a plant drawn as the object
+func entityOf(objectID int) (string, int, bool) {
+	if creature, ok := assets.GetCreatureByObjectID(objectID); ok {
+		return CreatureKind, creature.ID, true
+	}
+	if plant, ok := plants.GetPlantByObjectID(objectID); ok {
+		return PlantKind, plant.ID, true
+	}
+	return "", 0, false
+}
+
+// objectID finds the game object drawn with the tile. Ground tiles which are no
+// objects themselves, e.g. terrain transitions, are resolved by their terrain.
+func (self *loader) objectID(gid uint32, ground bool) (int, error) {
+	var tileset *tmx.Tileset
+	for i := range self.tilesets {
+		if self.tilesets[i].FirstGID <= gid {
+			tileset = &self.tilesets[i]
+		}
+	}
+	if tileset == nil || len(tileset.Image) == 0 {
+		return 0, fmt.Errorf("no tileset image for the tile %d", gid)
+	}
+	spritesheet, ok := assets.GetSpritesheetByFilename(tileset.Image[0].Source)
+	if !ok {
+		return 0, fmt.Errorf("unknown tileset image '%s'", tileset.Image[0].Source)
+	}
+	spriteID := int(gid - tileset.FirstGID)
+	if object, ok := assets.GetObjectBySprite(spritesheet.ID, spriteID); ok {
+		return object.ID, nil
+	}
+	if ground && assets.Tileset != nil && tileset.Name == assets.Tileset.Name {
+		if corners, ok := assets.Tileset.CornersOf(spriteID); ok {
+			if t := dominantTerrain(corners); t != "" {
+				return assets.GetObjectByTerrain(t).ID, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no object is drawn with the sprite %d of '%s'", spriteID, tileset.Name)
+}
+
+// dominantTerrain is the terrain at most of the corners that the game has an
+// object for
+func dominantTerrain(corners terrain.Corners) string {
+	count := make(map[string]int)
+	result := ""
+	for _, c := range corners {
+		if _, ok := assets.ObjectByTerrain[c]; !ok {
+			continue
+		}
+		count[c]++
+		if result == "" || count[c] > count[result] {
+			result = c
+		}
+	}
+	return result
+}
+
+func (self *loader) object(o *tmx.Object) error {
+	entity := Entity{Kind: o.Type, Properties: make(map[string]interface{})}
+	for _, p := range o.Properties {
+		switch p.Name {
+		case "kind":
+			entity.Kind = p.Value
+		case "creature_id", "plant_id":
+			id, err := strconv.Atoi(p.Value)
+			if err != nil {
+				return fmt.Errorf("object #%d: %s", o.ID, err)
+			}
+			entity.ID = id
+			if entity.Kind == "" {
+				entity.Kind = strings.TrimSuffix(p.Name, "_id")
+			}
+		}
+		if util.ContainsStr(reservedProperties, p.Name) {
+			continue
+		}
+		value, err := propertyValue(p)
+		if err != nil {
+			return fmt.Errorf("object #%d: %s", o.ID, err)
+		}
+		entity.Properties[p.Name] = value
+	}
+
+	y := o.Y
+	// Unlike layer data, objects keep the flip flags in their GIDs
+	gid := o.GID &^ (tmx.HorizontalFlipFlag | tmx.VerticalFlipFlag | tmx.DiagonalFlipFlag)
+	if gid != 0 {
+		// Tile objects are aligned to their bottom-left corner
+		y -= o.Height
+		if entity.ID == 0 {
+			objectID, err := self.objectID(gid, false)
+			if err != nil {
+				return fmt.Errorf("object #%d: %s", o.ID, err)
+			}
+			kind, id, ok := entityOf(objectID)
+			if !ok {
+				return fmt.Errorf("object #%d: the object %d is neither a creature nor a plant", o.ID, objectID)
+			}
+			entity.Kind, entity.ID, entity.ObjectID = kind, id, objectID
+		}
+	}
+	if entity.Kind != CreatureKind && entity.Kind != PlantKind {
+		return fmt.Errorf("object #%d: unknown kind '%s'", o.ID, entity.Kind)
+	}
+	entity.X = int(o.X) / self.raw.TileWidth
+	entity.Y = int(y) / self.raw.TileHeight
+	self.result.Entities = append(self.result.Entities, entity)
+	return nil
+}
+
+func propertyValue(p tmx.Property) (interface{}, error) {
+	switch p.Type {
+	case "int":
+		return strconv.Atoi(p.Value)
+	case "float":
+		return strconv.ParseFloat(p.Value, 64)
+	case "bool":
+		return strconv.ParseBool(p.Value)
+	}
+	return p.Value, nil
+}
@@ -13,3 +13,35 @@ func ToGridPosition(x float32, y float32) (float32, float32) {
 func ToPoint(i int, j int) *engo.Point {
 	return &engo.Point{float32(i * config.SpriteWidth), float32(j * config.SpriteHeight)}
 }
+
+// ToGridIndex returns the column and the row of the tile at the given position
+func ToGridIndex(x float32, y float32) (int, int) {
+	return int(x / float32(config.SpriteWidth)), int(y / float32(config.SpriteHeight))
+}
+
+// WorldSize is the size of the world, in tiles
+type WorldSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Bounds of the world, in pixels
+func (self WorldSize) Bounds() engo.AABB {
+	return engo.AABB{
+		Min: engo.Point{X: 0, Y: 0},
+		Max: engo.Point{
+			X: float32(self.Width * config.SpriteWidth),
+			Y: float32(self.Height * config.SpriteHeight),
+		},
+	}
+}
+
+// Fit grows the size to include the tile at the given grid index
+func (self *WorldSize) Fit(x int, y int) {
+	if x >= self.Width {
+		self.Width = x + 1
+	}
+	if y >= self.Height {
+		self.Height = y + 1
+	}
+}
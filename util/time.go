@@ -19,3 +19,12 @@ func FormatDuration(d time.Duration) string {
 		return fmt.Sprintf("%ds", s)
 	}
 }
+
+// FormatAge formats a long in-game duration in days and hours
+func FormatAge(d time.Duration) string {
+	h := int64(d / time.Hour)
+	if h < 24 {
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dd%02dh", h/24, h%24)
+}
@@ -1,11 +1,21 @@
 package util
 
 import (
+	"hash/fnv"
 	"math/rand"
 )
 
-func Roll(desiredStdDev float64, desiredMean float64) float64 {
-	return rand.NormFloat64()*desiredStdDev + desiredMean
+// NewRand returns a random number generator for one purpose (e.g. "world" or
+// "creatures") of the world with the given seed. Each purpose gets its own
+// stream, so that e.g. spawning a creature doesn't change the generated terrain.
+func NewRand(seed int64, stream string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(stream))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+func Roll(r *rand.Rand, desiredStdDev float64, desiredMean float64) float64 {
+	return r.NormFloat64()*desiredStdDev + desiredMean
 }
 
 func ContainsInt(s []int, e int) bool {